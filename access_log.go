@@ -0,0 +1,225 @@
+package go_sio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// CommonLogFormat is the NCSA common log format as an nginx log_format.
+	CommonLogFormat = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent`
+	// CombinedLogFormat is the combined log format, nginx's default.
+	CombinedLogFormat = CommonLogFormat + ` "$http_referer" "$http_user_agent"`
+
+	accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+)
+
+var (
+	ErrInvalidAccessLog    = errors.New("invalid access log line")
+	ErrInvalidAccessFormat = errors.New("invalid access log format")
+
+	accessLogVar = regexp.MustCompile(`\$(?:\{([A-Za-z0-9_]+)\}|([A-Za-z0-9_]+))`)
+)
+
+// AccessLogEntry is a parsed access log line. Well-known nginx variables are
+// converted into the typed fields; Fields holds the raw text of every
+// variable in the format. A "-" placeholder leaves the typed field empty.
+type AccessLogEntry struct {
+	RemoteAddr    string
+	RemoteUser    string
+	Time          time.Time
+	Request       string
+	Method        string
+	URI           string
+	Protocol      string
+	Status        int
+	BodyBytesSent int64
+	Referer       string
+	UserAgent     string
+	Fields        map[string]string
+}
+
+// AccessLogParser parses lines written with an nginx log_format layout, such
+// as CommonLogFormat or CombinedLogFormat.
+type AccessLogParser struct {
+	re   *regexp.Regexp
+	vars []string
+}
+
+// NewAccessLogParser compiles an nginx log_format template. Variables are
+// written as $name or ${name}; all other text must match literally. A
+// variable ends at the first occurrence of the literal text that follows it,
+// and a variable inside double quotes may contain backslash-escaped quotes.
+func NewAccessLogParser(format string) (*AccessLogParser, error) {
+	matches := accessLogVar.FindAllStringSubmatchIndex(format, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: no variables in %q", ErrInvalidAccessFormat, format)
+	}
+
+	p := &AccessLogParser{}
+	seen := make(map[string]bool)
+	var pattern strings.Builder
+	pattern.WriteByte('^')
+	prev := 0
+	for i, m := range matches {
+		literal := format[prev:m[0]]
+		if strings.Contains(literal, "$") {
+			return nil, fmt.Errorf("%w: bad variable in %q", ErrInvalidAccessFormat, literal)
+		}
+		pattern.WriteString(regexp.QuoteMeta(literal))
+
+		var name string
+		if m[2] >= 0 {
+			name = format[m[2]:m[3]]
+		} else {
+			name = format[m[4]:m[5]]
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate variable $%s", ErrInvalidAccessFormat, name)
+		}
+		seen[name] = true
+		p.vars = append(p.vars, name)
+
+		next := len(format)
+		if i+1 < len(matches) {
+			next = matches[i+1][0]
+		}
+		switch {
+		case m[1] == len(format):
+			pattern.WriteString(`(.*)`)
+		case m[1] == next:
+			pattern.WriteString(`(.*?)`)
+		case format[m[1]] == '"':
+			pattern.WriteString(`((?:[^"\\]|\\.)*)`)
+		default:
+			_, size := utf8.DecodeRuneInString(format[m[1]:])
+			pattern.WriteString(`([^` + regexp.QuoteMeta(format[m[1]:m[1]+size]) + `]*)`)
+		}
+		prev = m[1]
+	}
+	if strings.Contains(format[prev:], "$") {
+		return nil, fmt.Errorf("%w: bad variable in %q", ErrInvalidAccessFormat, format[prev:])
+	}
+	pattern.WriteString(regexp.QuoteMeta(format[prev:]))
+	pattern.WriteByte('$')
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccessFormat, err)
+	}
+	p.re = re
+	return p, nil
+}
+
+// Parse parses one access log line. A trailing line terminator is ignored.
+func (p *AccessLogParser) Parse(line string) (*AccessLogEntry, error) {
+	line, _ = cutLineEnd(line)
+	values := p.re.FindStringSubmatch(line)
+	if values == nil {
+		return nil, fmt.Errorf("%w: does not match format", ErrInvalidAccessLog)
+	}
+
+	e := &AccessLogEntry{Fields: make(map[string]string, len(p.vars))}
+	for i, name := range p.vars {
+		raw := values[i+1]
+		e.Fields[name] = raw
+		value := raw
+		if value == "-" {
+			value = ""
+		}
+		var err error
+		switch name {
+		case "remote_addr":
+			e.RemoteAddr = value
+		case "remote_user":
+			e.RemoteUser = value
+		case "time_local":
+			e.Time, err = time.Parse(accessLogTimeLayout, raw)
+		case "time_iso8601":
+			e.Time, err = time.Parse(time.RFC3339, raw)
+		case "request":
+			e.Request = value
+			e.Method, e.URI, e.Protocol = splitRequest(value)
+		case "status":
+			e.Status, err = strconv.Atoi(raw)
+		case "body_bytes_sent":
+			if value != "" {
+				e.BodyBytesSent, err = strconv.ParseInt(value, 10, 64)
+			}
+		case "http_referer":
+			e.Referer = value
+		case "http_user_agent":
+			e.UserAgent = value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad $%s %q", ErrInvalidAccessLog, name, raw)
+		}
+	}
+	return e, nil
+}
+
+func splitRequest(request string) (method, uri, protocol string) {
+	method, rest, _ := strings.Cut(request, " ")
+	uri, protocol, _ = strings.Cut(rest, " ")
+	return method, uri, protocol
+}
+
+// ToJSON converts an access log line to a compact JSON object keyed by
+// variable name, in format order. Times become RFC 3339 strings, status and
+// byte counts become numbers, "$request" is followed by its method, uri and
+// protocol parts, and "-" placeholders become null.
+func (p *AccessLogParser) ToJSON(line string) (string, error) {
+	e, err := p.Parse(line)
+	if err != nil {
+		return "", err
+	}
+	fields := make([]jsonField, 0, len(p.vars)+3)
+	for _, name := range p.vars {
+		raw := e.Fields[name]
+		var value any = raw
+		switch {
+		case raw == "-":
+			value = nil
+		case name == "time_local" || name == "time_iso8601":
+			value = e.Time.Format(time.RFC3339)
+		case name == "status":
+			value = e.Status
+		case name == "body_bytes_sent":
+			value = e.BodyBytesSent
+		}
+		fields = append(fields, jsonField{name, value})
+		if name == "request" && e.Method != "" {
+			fields = append(fields,
+				jsonField{"method", e.Method},
+				jsonField{"uri", e.URI},
+				jsonField{"protocol", e.Protocol},
+			)
+		}
+	}
+	out, _ := marshalJSONObject(fields) // strings and numbers always encode
+	return out, nil
+}
+
+// JSONFilter returns a StringLineFilter that converts lines with ToJSON,
+// keeping their line terminator. Malformed lines are dropped and, when
+// reject is not nil, written to it unchanged.
+func (p *AccessLogParser) JSONFilter(reject io.Writer) StringLineFilter {
+	return func(in string) (string, error) {
+		out, err := p.ToJSON(in)
+		if err != nil {
+			if reject == nil {
+				return "", nil
+			}
+			_, err = io.WriteString(reject, in)
+			return "", err
+		}
+		_, end := cutLineEnd(in)
+		return out + end, nil
+	}
+}
@@ -0,0 +1,206 @@
+package go_sio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const combinedLine = `203.0.113.7 - alice [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif?a=1 HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;Nav)"` + "\n"
+
+func TestNewAccessLogParser_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		msg    string
+	}{
+		{"no variables", "plain text", "no variables"},
+		{"dangling dollar", "$remote_addr $ $status", "bad variable"},
+		{"trailing dollar", "$remote_addr $", "bad variable"},
+		{"duplicate", "$status $status", "duplicate variable $status"},
+		{"invalid UTF-8", "$remote_addr\xff$status", "invalid UTF-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAccessLogParser(tt.format)
+			if !errors.Is(err, ErrInvalidAccessFormat) {
+				t.Fatalf("Expected ErrInvalidAccessFormat, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expected error to contain %q, got %q", tt.msg, err.Error())
+			}
+		})
+	}
+}
+
+func TestAccessLogParser_NonASCIISeparator(t *testing.T) {
+	p, err := NewAccessLogParser("$remote_user→$status→$request")
+	if err != nil {
+		t.Fatalf("NewAccessLogParser failed: %v", err)
+	}
+	// "€" shares its first byte with the separator
+	e, err := p.Parse("b€b→404→GET / HTTP/1.1\n")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if e.RemoteUser != "b€b" || e.Status != 404 || e.URI != "/" {
+		t.Errorf("Unexpected fields: %+v", e)
+	}
+}
+
+func TestAccessLogParser_ParseCombined(t *testing.T) {
+	p, err := NewAccessLogParser(CombinedLogFormat)
+	if err != nil {
+		t.Fatalf("NewAccessLogParser failed: %v", err)
+	}
+
+	e, err := p.Parse(combinedLine)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	expectedTime := time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC)
+	if !e.Time.Equal(expectedTime) {
+		t.Errorf("Expected time %v, got %v", expectedTime, e.Time)
+	}
+	if e.RemoteAddr != "203.0.113.7" || e.RemoteUser != "alice" {
+		t.Errorf("Unexpected client fields: %+v", e)
+	}
+	if e.Method != "GET" || e.URI != "/apache_pb.gif?a=1" || e.Protocol != "HTTP/1.0" {
+		t.Errorf("Unexpected request fields: %+v", e)
+	}
+	if e.Status != 200 || e.BodyBytesSent != 2326 {
+		t.Errorf("Unexpected status fields: %+v", e)
+	}
+	if e.Referer != "http://www.example.com/start.html" || e.UserAgent != "Mozilla/4.08 [en] (Win98; I ;Nav)" {
+		t.Errorf("Unexpected header fields: %+v", e)
+	}
+	if len(e.Fields) != 8 || e.Fields["remote_user"] != "alice" {
+		t.Errorf("Unexpected raw fields: %v", e.Fields)
+	}
+}
+
+func TestAccessLogParser_CustomFormat(t *testing.T) {
+	p, err := NewAccessLogParser(`${time_iso8601}|$remote_addr|"$request"|$status|$request_time$upstream`)
+	if err != nil {
+		t.Fatalf("NewAccessLogParser failed: %v", err)
+	}
+
+	e, err := p.Parse(`2024-05-01T10:00:00+00:00|10.0.0.1|"POST /api \"quoted\" HTTP/2.0"|503|0.120up`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !e.Time.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected time %v", e.Time)
+	}
+	if e.Request != `POST /api \"quoted\" HTTP/2.0` || e.Status != 503 {
+		t.Errorf("Unexpected fields: %+v", e)
+	}
+	// Adjacent variables split lazily
+	if e.Fields["request_time"] != "" || e.Fields["upstream"] != "0.120up" {
+		t.Errorf("Unexpected adjacent fields: %v", e.Fields)
+	}
+}
+
+func TestAccessLogParser_Placeholders(t *testing.T) {
+	p, _ := NewAccessLogParser(CombinedLogFormat)
+	e, err := p.Parse(`::1 - - [10/Oct/2000:13:55:36 +0000] "-" 400 - "-" "-"`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if e.RemoteUser != "" || e.Request != "" || e.Method != "" || e.BodyBytesSent != 0 || e.Referer != "" || e.UserAgent != "" {
+		t.Errorf("Expected placeholders to be empty: %+v", e)
+	}
+}
+
+func TestAccessLogParser_ParseErrors(t *testing.T) {
+	p, _ := NewAccessLogParser(CombinedLogFormat)
+	iso, _ := NewAccessLogParser(`$time_iso8601 $status`)
+	tests := []struct {
+		name   string
+		parser *AccessLogParser
+		line   string
+		msg    string
+	}{
+		{"no match", p, "garbage\n", "does not match format"},
+		{"bad time", p, `1.2.3.4 - - [yesterday] "GET / HTTP/1.1" 200 1 "-" "-"`, `bad $time_local "yesterday"`},
+		{"bad status", p, `1.2.3.4 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.1" ok 1 "-" "-"`, `bad $status "ok"`},
+		{"bad bytes", p, `1.2.3.4 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.1" 200 many "-" "-"`, `bad $body_bytes_sent "many"`},
+		{"bad iso time", iso, "today 200", `bad $time_iso8601 "today"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parser.Parse(tt.line)
+			if !errors.Is(err, ErrInvalidAccessLog) {
+				t.Fatalf("Expected ErrInvalidAccessLog, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expected error to contain %q, got %q", tt.msg, err.Error())
+			}
+		})
+	}
+}
+
+func TestAccessLogParser_ToJSON(t *testing.T) {
+	p, _ := NewAccessLogParser(CombinedLogFormat)
+
+	got, err := p.ToJSON(combinedLine)
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	expected := `{"remote_addr":"203.0.113.7","remote_user":"alice","time_local":"2000-10-10T13:55:36-07:00",` +
+		`"request":"GET /apache_pb.gif?a=1 HTTP/1.0","method":"GET","uri":"/apache_pb.gif?a=1","protocol":"HTTP/1.0",` +
+		`"status":200,"body_bytes_sent":2326,"http_referer":"http://www.example.com/start.html",` +
+		`"http_user_agent":"Mozilla/4.08 [en] (Win98; I ;Nav)"}`
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	got, _ = p.ToJSON(`::1 - - [10/Oct/2000:13:55:36 +0000] "-" 400 - "-" "-"`)
+	expected = `{"remote_addr":"::1","remote_user":null,"time_local":"2000-10-10T13:55:36Z","request":null,` +
+		`"status":400,"body_bytes_sent":null,"http_referer":null,"http_user_agent":null}`
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	if _, err := p.ToJSON("garbage"); !errors.Is(err, ErrInvalidAccessLog) {
+		t.Errorf("Expected ErrInvalidAccessLog, got %v", err)
+	}
+}
+
+func TestAccessLogParser_JSONFilter(t *testing.T) {
+	p, _ := NewAccessLogParser(CommonLogFormat)
+	data := `1.2.3.4 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.1" 200 10` + "\n" +
+		"malformed line\n" +
+		`1.2.3.4 - bob [10/Oct/2000:13:55:37 -0700] "GET /x HTTP/1.1" 404 0`
+
+	var rejects bytes.Buffer
+	out, err := io.ReadAll(NewStreamReader(strings.NewReader(data), p.JSONFilter(&rejects)))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	lines := strings.Split(string(out), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"status":200`) || !strings.Contains(lines[1], `"remote_user":"bob"`) {
+		t.Errorf("Unexpected output %q", string(out))
+	}
+	if rejects.String() != "malformed line\n" {
+		t.Errorf("Expected rejected line, got %q", rejects.String())
+	}
+
+	// Without a reject writer malformed lines are just dropped
+	out, err = io.ReadAll(NewStreamReader(strings.NewReader("malformed\n"), p.JSONFilter(nil)))
+	if err != nil || len(out) != 0 {
+		t.Errorf("Expected no output, got %q (%v)", string(out), err)
+	}
+
+	// Reject write errors abort reading
+	writeErr := errors.New("write error")
+	_, err = io.ReadAll(NewStreamReader(strings.NewReader("malformed\n"), p.JSONFilter(&failingWriter{err: writeErr})))
+	if err != writeErr {
+		t.Errorf("Expected %v, got %v", writeErr, err)
+	}
+}
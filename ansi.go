@@ -0,0 +1,119 @@
+package go_sio
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// NewANSIStripFilter returns a StringLineFilter that removes ANSI escape
+// sequences (CSI, OSC and other ESC sequences, in 7-bit and 8-bit form) and
+// C0/C1 control characters from each line. Carriage returns and backspaces
+// move a virtual cursor and later text overwrites earlier text, so
+// "\r"-redrawn progress lines collapse to their final state; "ESC [ K"
+// erase-in-line sequences are honoured for the same reason. Tabs are kept
+// when keepTabs is set. Line terminators are normalized to "\n".
+func NewANSIStripFilter(keepTabs bool) StringLineFilter {
+	return func(in string) (string, error) {
+		line, end := cutLineEnd(in)
+		if end != "" {
+			end = "\n"
+		}
+
+		// cells holds the bytes shown at each cursor position
+		var cells []string
+		cursor := 0
+		put := func(s string) {
+			if cursor < len(cells) {
+				cells[cursor] = s
+			} else {
+				cells = append(cells, s)
+			}
+			cursor++
+		}
+
+		for i := 0; i < len(line); {
+			r, size := utf8.DecodeRuneInString(line[i:])
+			switch {
+			case r == 0x1b && i+1 < len(line) && line[i+1] == '[':
+				i = skipCSI(line, i+2, &cells, &cursor)
+				continue
+			case r == 0x9b:
+				i = skipCSI(line, i+size, &cells, &cursor)
+				continue
+			case r == 0x1b && i+1 < len(line) && strings.IndexByte("]PX^_", line[i+1]) >= 0:
+				i = skipString(line, i+2)
+				continue
+			case r == 0x9d || r == 0x90 || r == 0x98 || r == 0x9e || r == 0x9f:
+				i = skipString(line, i+size)
+				continue
+			case r == 0x1b:
+				i++
+				for i < len(line) && line[i] >= 0x20 && line[i] <= 0x2f {
+					i++
+				}
+				if i < len(line) {
+					i++
+				}
+				continue
+			case r == '\r':
+				cursor = 0
+			case r == '\b':
+				cursor = max(cursor-1, 0)
+			case r == '\t' && keepTabs:
+				put("\t")
+			case r < 0x20 || (r >= 0x7f && r <= 0x9f):
+			default:
+				put(line[i : i+size])
+			}
+			i += size
+		}
+		return strings.Join(cells, "") + end, nil
+	}
+}
+
+// skipCSI skips the parameters, intermediates and final byte of a control
+// sequence starting at i and applies erase-in-line ('K').
+func skipCSI(line string, i int, cells *[]string, cursor *int) int {
+	start := i
+	for i < len(line) && line[i] >= 0x20 && line[i] <= 0x3f {
+		i++
+	}
+	if i == len(line) {
+		return i
+	}
+	if line[i] != 'K' {
+		return i + 1
+	}
+	// Trailing blanks are invisible, so erasing to the end truncates
+	switch line[start:i] {
+	case "", "0":
+		*cells = (*cells)[:min(*cursor, len(*cells))]
+	case "1":
+		for j := 0; j <= *cursor && j < len(*cells); j++ {
+			(*cells)[j] = " "
+		}
+	case "2":
+		*cells = (*cells)[:min(*cursor, len(*cells))]
+		for j := range *cells {
+			(*cells)[j] = " "
+		}
+	}
+	return i + 1
+}
+
+// skipString skips an OSC, DCS, SOS, PM or APC string starting at i up to and
+// including its BEL or ST terminator.
+func skipString(line string, i int) int {
+	for i < len(line) {
+		switch {
+		case line[i] == 0x07:
+			return i + 1
+		case line[i] == 0x1b && i+1 < len(line) && line[i+1] == '\\':
+			return i + 2
+		case strings.HasPrefix(line[i:], "\u009c"):
+			return i + len("\u009c")
+		}
+		i++
+	}
+	return i
+}
@@ -0,0 +1,72 @@
+package go_sio
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewANSIStripFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		keepTabs bool
+		expected string
+	}{
+		{"plain", "hello world\n", false, "hello world\n"},
+		{"sgr colors", "\x1b[1;31mERROR\x1b[0m: failed\n", false, "ERROR: failed\n"},
+		{"8-bit csi", "\u009b32mok\u009b0m", false, "ok"},
+		{"cursor movement", "a\x1b[2Ab\x1b[10;5Hc", false, "abc"},
+		{"private mode", "\x1b[?25lhidden cursor\x1b[?25h", false, "hidden cursor"},
+		{"unterminated csi", "text\x1b[31", false, "text"},
+		{"osc title with bel", "\x1b]0;my title\x07prompt$ ", false, "prompt$ "},
+		{"osc hyperlink with st", "\x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\", false, "link"},
+		{"8-bit osc and st", "\u009d0;title\u009cafter", false, "after"},
+		{"unterminated osc", "before\x1b]0;title", false, "before"},
+		{"dcs string", "\x1bPq#0;2;0;0;0\x1b\\img", false, "img"},
+		{"charset designation", "\x1b(Bascii\x1b=", false, "ascii"},
+		{"lone escape at end", "end\x1b", false, "end"},
+		{"c0 and c1 controls", "a\x00b\x07c\x7fd\u0085e", false, "abcde"},
+		{"tabs dropped", "a\tb\n", false, "ab\n"},
+		{"tabs kept", "a\tb\n", true, "a\tb\n"},
+		{"crlf normalized", "line\r\n", false, "line\n"},
+		{"progress overwrite", "Progress  10%\rProgress  50%\rProgress 100%\n", false, "Progress 100%\n"},
+		{"shorter overwrite keeps tail", "abcdef\rxy", false, "xycdef"},
+		{"erase to end", "downloading 10%\r\x1b[Kdone\n", false, "done\n"},
+		{"erase to end explicit", "abcdef\r\x1b[0Kxy", false, "xy"},
+		{"erase whole line", "abcdef\x1b[2K\rxy", false, "xy    "},
+		{"erase to start", "abcdef\rab\x1b[1K", false, "   def"},
+		{"backspace spinner", "working |\b/\b-\b\\", false, "working \\"},
+		{"backspace at start", "\b\bx", false, "x"},
+		{"utf8 kept", "héllo \x1b[1m世界\x1b[0m", false, "héllo 世界"},
+		{"invalid utf8 kept", "a\xffb", false, "a\xffb"},
+		{"only escapes", "\x1b[0m\n", false, "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewANSIStripFilter(tt.keepTabs)(tt.in)
+			if err != nil {
+				t.Fatalf("Filter failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewANSIStripFilter_JSONAfterStrip(t *testing.T) {
+	data := "\x1b[32m{\"level\":\"info\"}\x1b[0m\n\x1b[2K\rnot json\n"
+	sr := NewStreamReader(strings.NewReader(data), NewANSIStripFilter(false))
+	rc := NewJSONFilterReadCloser(io.NopCloser(sr))
+	defer rc.Close()
+
+	out, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "{\"level\":\"info\"}\n" {
+		t.Errorf("Unexpected output %q", string(out))
+	}
+}
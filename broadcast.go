@@ -0,0 +1,205 @@
+package go_sio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+)
+
+var ErrConsumerDropped = errors.New("consumer fell behind and was dropped")
+
+// defaultConsumerBuffer is the per-consumer buffer size used when none is
+// given.
+const defaultConsumerBuffer = 64 * 1024
+
+// SlowConsumerPolicy selects what a Broadcaster does when a consumer's
+// buffer is full.
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerBlock stops reading upstream until the consumer catches
+	// up, so every consumer goes at the pace of the slowest one.
+	SlowConsumerBlock SlowConsumerPolicy = iota
+	// SlowConsumerDrop stops feeding the consumer. It reads what it has
+	// buffered and then ErrConsumerDropped.
+	SlowConsumerDrop
+)
+
+// BroadcastOption configures a Broadcaster.
+type BroadcastOption func(*Broadcaster)
+
+// WithConsumerBuffer sets how many bytes each consumer may have buffered
+// before the slow consumer policy applies. The default is 64 KiB.
+func WithConsumerBuffer(n int) BroadcastOption {
+	return func(b *Broadcaster) { b.limit = max(n, 1) }
+}
+
+// WithSlowConsumerPolicy sets the slow consumer policy. The default is
+// SlowConsumerBlock.
+func WithSlowConsumerPolicy(p SlowConsumerPolicy) BroadcastOption {
+	return func(b *Broadcaster) { b.policy = p }
+}
+
+// Broadcaster reads an upstream io.ReadCloser once and hands the same data
+// to any number of consumers, each read at its own pace from its own
+// goroutine. Unlike TeeReaderCloser, no consumer is primary.
+//
+// Upstream is read by a goroutine started on the first consumer Read, so
+// consumers created before then see all of the data. A consumer created
+// later sees the data from that point on. Upstream is closed when the last
+// consumer is closed.
+type Broadcaster struct {
+	upstream  io.ReadCloser
+	limit     int
+	policy    SlowConsumerPolicy
+	mu        sync.Mutex
+	cond      *sync.Cond
+	consumers []*BroadcastConsumer
+	started   bool
+	closed    bool
+	err       error
+}
+
+// NewBroadcaster returns a Broadcaster of rc. Returns nil when rc is nil.
+func NewBroadcaster(rc io.ReadCloser, opts ...BroadcastOption) *Broadcaster {
+	if rc == nil {
+		return nil
+	}
+	b := &Broadcaster{upstream: rc, limit: defaultConsumerBuffer}
+	b.cond = sync.NewCond(&b.mu)
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// NewConsumer adds a consumer. It fails with ErrClosed once upstream has
+// been closed.
+func (b *Broadcaster) NewConsumer() (*BroadcastConsumer, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	c := &BroadcastConsumer{b: b}
+	b.consumers = append(b.consumers, c)
+	c.state.track("BroadcastConsumer")
+	return c, nil
+}
+
+// pump reads upstream until it fails or ends and hands the data to every
+// consumer.
+func (b *Broadcaster) pump() {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := b.upstream.Read(buf)
+		b.mu.Lock()
+		if n > 0 {
+			b.deliver(buf[:n])
+		}
+		if err != nil {
+			b.err = err
+			b.cond.Broadcast()
+			b.mu.Unlock()
+			return
+		}
+		b.mu.Unlock()
+	}
+}
+
+// deliver appends data to every consumer's buffer, applying the slow
+// consumer policy to full ones. A chunk larger than the buffer limit is
+// accepted into an empty buffer. It is called with b.mu held.
+func (b *Broadcaster) deliver(data []byte) {
+	full := func(c *BroadcastConsumer) bool {
+		return c.buffer.Len() > 0 && c.buffer.Len()+len(data) > b.limit
+	}
+	// Consumers may close while this waits, so go over a copy
+	for _, c := range slices.Clone(b.consumers) {
+		if c.dropped || c.detached {
+			continue
+		}
+		if b.policy == SlowConsumerDrop && full(c) {
+			c.dropped = true
+			continue
+		}
+		for full(c) && !c.detached {
+			b.cond.Wait()
+		}
+		if !c.detached {
+			c.buffer.Write(data)
+		}
+	}
+	b.cond.Broadcast()
+}
+
+// BroadcastConsumer is one reader of a Broadcaster. It is safe to Close
+// from another goroutine while a Read is blocked.
+type BroadcastConsumer struct {
+	b        *Broadcaster
+	buffer   bytes.Buffer
+	dropped  bool
+	detached bool // closed and removed from the Broadcaster
+	state    closeState
+}
+
+// Read blocks until data is buffered for the consumer, upstream ends or
+// fails, or the consumer is closed. Upstream's io.EOF or error is returned
+// to every consumer once it has read its buffer.
+func (c *BroadcastConsumer) Read(p []byte) (n int, err error) {
+	if c.state.isClosed() {
+		return 0, ErrClosed
+	}
+	b := c.b
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.started {
+		b.started = true
+		go b.pump()
+	}
+	for c.buffer.Len() == 0 && !c.dropped && b.err == nil && !c.detached {
+		b.cond.Wait()
+	}
+	switch {
+	case c.detached:
+		return 0, ErrClosed
+	case c.buffer.Len() > 0:
+		n, _ = c.buffer.Read(p)
+		b.cond.Broadcast()
+		return n, nil
+	case c.dropped:
+		return 0, ErrConsumerDropped
+	}
+	return 0, b.err
+}
+
+// Dropped reports whether the consumer fell behind under SlowConsumerDrop.
+func (c *BroadcastConsumer) Dropped() bool {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	return c.dropped
+}
+
+// Close removes the consumer and unblocks its waiting Read. Closing the last
+// consumer closes upstream and returns its Close error.
+func (c *BroadcastConsumer) Close() error {
+	return c.state.close(func() error {
+		b := c.b
+		b.mu.Lock()
+		c.detached = true
+		c.buffer.Reset()
+		b.consumers = slices.DeleteFunc(b.consumers, func(other *BroadcastConsumer) bool { return other == c })
+		last := len(b.consumers) == 0
+		if last {
+			b.closed = true
+		}
+		b.cond.Broadcast()
+		b.mu.Unlock()
+		if last {
+			return b.upstream.Close()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,183 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func newConsumers(t *testing.T, b *Broadcaster, n int) []*BroadcastConsumer {
+	t.Helper()
+	var consumers []*BroadcastConsumer
+	for range n {
+		c, err := b.NewConsumer()
+		if err != nil {
+			t.Fatalf("NewConsumer failed: %v", err)
+		}
+		consumers = append(consumers, c)
+	}
+	return consumers
+}
+
+func TestBroadcaster(t *testing.T) {
+	TrackLeaks(t)
+	data := strings.Repeat("a line of the upstream log\n", 10000)
+	upstream := newMockReadCloser(data)
+	b := NewBroadcaster(upstream, WithConsumerBuffer(1024))
+	consumers := newConsumers(t, b, 3)
+
+	var wg sync.WaitGroup
+	outputs := make([]string, len(consumers))
+	for i, c := range consumers {
+		wg.Go(func() {
+			out, err := io.ReadAll(c)
+			if err != nil {
+				t.Errorf("Consumer %d failed: %v", i, err)
+			}
+			outputs[i] = string(out)
+		})
+	}
+	wg.Wait()
+
+	for i, out := range outputs {
+		if out != data {
+			t.Errorf("Consumer %d got %d bytes, expected %d", i, len(out), len(data))
+		}
+	}
+	for i, c := range consumers {
+		if upstream.closed {
+			t.Fatalf("Expected upstream open before consumer %d closed", i)
+		}
+		if err := c.Close(); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+	}
+	if !upstream.closed {
+		t.Error("Expected upstream closed with the last consumer")
+	}
+	if _, err := b.NewConsumer(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestBroadcaster_Drop(t *testing.T) {
+	pr, pw := io.Pipe()
+	b := NewBroadcaster(pr, WithConsumerBuffer(4), WithSlowConsumerPolicy(SlowConsumerDrop))
+	consumers := newConsumers(t, b, 2)
+	fast, slow := consumers[0], consumers[1]
+	defer fast.Close()
+	defer slow.Close()
+
+	go func() {
+		_, _ = pw.Write([]byte("abcd"))
+	}()
+	buf := make([]byte, 8)
+	if n, err := fast.Read(buf); err != nil || string(buf[:n]) != "abcd" {
+		t.Fatalf("Expected %q, got %q, %v", "abcd", string(buf[:n]), err)
+	}
+	go func() {
+		_, _ = pw.Write([]byte("ef"))
+		_, _ = pw.Write([]byte("g"))
+		_ = pw.Close()
+	}()
+	out, err := io.ReadAll(fast)
+	if err != nil || string(out) != "efg" {
+		t.Errorf("Expected %q for the fast consumer, got %q, %v", "efg", string(out), err)
+	}
+	if fast.Dropped() || !slow.Dropped() {
+		t.Errorf("Expected only the slow consumer dropped, got %v, %v", fast.Dropped(), slow.Dropped())
+	}
+	out, err = io.ReadAll(slow)
+	if !errors.Is(err, ErrConsumerDropped) || string(out) != "abcd" {
+		t.Errorf("Expected %q and ErrConsumerDropped, got %q, %v", "abcd", string(out), err)
+	}
+}
+
+func TestBroadcaster_CloseUnblocksSlowConsumer(t *testing.T) {
+	b := NewBroadcaster(io.NopCloser(iotest.OneByteReader(strings.NewReader("abcdefghij"))), WithConsumerBuffer(2))
+	consumers := newConsumers(t, b, 3)
+	idle, slow, fast := consumers[0], consumers[1], consumers[2]
+	defer fast.Close()
+
+	// Neither idle consumer reads, so upstream waits on each until it closes
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = idle.Close()
+		time.Sleep(10 * time.Millisecond)
+		_ = slow.Close()
+	}()
+	out, err := io.ReadAll(fast)
+	if err != nil || string(out) != "abcdefghij" {
+		t.Errorf("Expected all data once the idle consumers closed, got %q, %v", string(out), err)
+	}
+}
+
+func TestBroadcaster_UpstreamError(t *testing.T) {
+	readErr := errors.New("read failed")
+	b := NewBroadcaster(NewReadCloser(&failingReader{data: "abc", err: readErr}, closerFunc(func() error { return nil })))
+	for i, c := range newConsumers(t, b, 2) {
+		out, err := io.ReadAll(c)
+		if !errors.Is(err, readErr) || string(out) != "abc" {
+			t.Errorf("Consumer %d: expected %q and %v, got %q, %v", i, "abc", readErr, string(out), err)
+		}
+		_ = c.Close()
+	}
+}
+
+func TestBroadcaster_LateConsumer(t *testing.T) {
+	pr, pw := io.Pipe()
+	b := NewBroadcaster(pr)
+	first := newConsumers(t, b, 1)[0]
+	defer first.Close()
+
+	go func() { _, _ = pw.Write([]byte("early\n")) }()
+	buf := make([]byte, 16)
+	if n, _ := first.Read(buf); string(buf[:n]) != "early\n" {
+		t.Fatalf("Expected %q, got %q", "early\n", string(buf[:n]))
+	}
+	late := newConsumers(t, b, 1)[0]
+	defer late.Close()
+	go func() {
+		_, _ = pw.Write([]byte("late\n"))
+		_ = pw.Close()
+	}()
+	for _, c := range []*BroadcastConsumer{late, first} {
+		if out, err := io.ReadAll(c); err != nil || string(out) != "late\n" {
+			t.Errorf("Expected %q, got %q, %v", "late\n", string(out), err)
+		}
+	}
+}
+
+func TestBroadcaster_Close(t *testing.T) {
+	closeErr := errors.New("close failed")
+	upstream, _ := io.Pipe()
+	b := NewBroadcaster(NewReadCloser(upstream, closerFunc(func() error {
+		_ = upstream.Close()
+		return closeErr
+	})))
+	consumers := newConsumers(t, b, 2)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = consumers[0].Close()
+	}()
+	if _, err := consumers[0].Read(make([]byte, 8)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed from a blocked Read, got %v", err)
+	}
+	if _, err := consumers[0].Read(make([]byte, 8)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if err := consumers[1].Close(); !errors.Is(err, closeErr) {
+		t.Errorf("Expected %v from the last Close, got %v", closeErr, err)
+	}
+}
+
+func TestBroadcaster_Nil(t *testing.T) {
+	if b := NewBroadcaster(nil); b != nil {
+		t.Error("Expected nil for a nil upstream")
+	}
+}
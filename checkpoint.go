@@ -0,0 +1,173 @@
+package go_sio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"sync"
+)
+
+// checkpointHeadSize is how much of the start of a file is hashed to
+// recognize it again.
+const checkpointHeadSize = 1024
+
+// Checkpoint records how far a file has been read. HeadHash is a SHA-256 of
+// the first HeadSize bytes, used to tell whether the file at the same path
+// is still the one that was read.
+type Checkpoint struct {
+	Offset   int64  `json:"offset"`
+	HeadSize int64  `json:"head_size"`
+	HeadHash string `json:"head_hash"`
+}
+
+// CheckpointStore persists checkpoints by key. Load reports false when there
+// is no checkpoint for key.
+type CheckpointStore interface {
+	Load(key string) (Checkpoint, bool, error)
+	Save(key string, cp Checkpoint) error
+}
+
+// FileCheckpointStore keeps all checkpoints in one JSON file. Save replaces
+// the file atomically through a temporary file and a rename. It is safe for
+// concurrent use within one process.
+type FileCheckpointStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+func (s *FileCheckpointStore) Load(key string) (Checkpoint, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.load()
+	cp, ok := all[key]
+	return cp, ok, err
+}
+
+func (s *FileCheckpointStore) Save(key string, cp Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	all[key] = cp
+	data, _ := json.Marshal(all) // plain structs always encode
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *FileCheckpointStore) load() (map[string]Checkpoint, error) {
+	all := make(map[string]Checkpoint)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// CheckpointReadCloser is a StreamReader over a file that can save how far
+// its output has been consumed and resume from there.
+type CheckpointReadCloser struct {
+	sr    *StreamReader
+	file  *os.File
+	key   string
+	store CheckpointStore
+	start int64
+	state closeState
+}
+
+// OpenCheckpointed opens the file at path and resumes after the checkpoint
+// saved in store under path, if its head fingerprint still matches and the
+// file is not shorter than the checkpoint. Otherwise it starts from the
+// beginning. Lines are filtered as by NewStreamReader with f and opts.
+//
+// Call Commit after processing the data returned so far; a restart then
+// resumes after the last committed line. Lines read but not committed are
+// read again, so processing is at-least-once. A line that failed is never
+// committed: the checkpoint stays before it, and the lines read after it are
+// read again too.
+func OpenCheckpointed(path string, store CheckpointStore, f StringLineFilter, opts ...StreamOption) (*CheckpointReadCloser, error) {
+	cp, ok, err := store.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &CheckpointReadCloser{file: file, key: path, store: store}
+	if ok && cp.Offset > 0 && validCheckpoint(file, cp) {
+		c.start = cp.Offset
+	}
+	c.sr = NewStreamReader(io.NewSectionReader(file, c.start, math.MaxInt64-c.start), f, opts...)
+	c.state.track("CheckpointReadCloser")
+	return c, nil
+}
+
+// validCheckpoint reports whether file is long enough for cp and starts with
+// the same bytes it did when cp was saved.
+func validCheckpoint(file *os.File, cp Checkpoint) bool {
+	if _, err := file.ReadAt(make([]byte, 1), cp.Offset-1); err != nil {
+		return false
+	}
+	return headHash(file, cp.HeadSize) == cp.HeadHash
+}
+
+// headHash hashes the first n bytes of r, or as many as can be read.
+func headHash(r io.ReaderAt, n int64) string {
+	h := sha256.New()
+	_, _ = io.Copy(h, io.NewSectionReader(r, 0, n))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *CheckpointReadCloser) Read(p []byte) (n int, err error) {
+	if c.state.isClosed() {
+		return 0, ErrClosed
+	}
+	return c.sr.Read(p)
+}
+
+// StartOffset returns the offset reading started at: the checkpoint that was
+// resumed, or 0.
+func (c *CheckpointReadCloser) StartOffset() int64 {
+	return c.start
+}
+
+// Offset returns the file offset just past the last fully consumed line, as
+// described for StreamReader.Offset.
+func (c *CheckpointReadCloser) Offset() int64 {
+	return c.start + c.sr.Offset()
+}
+
+// Commit saves Offset and the file's head fingerprint to the store.
+func (c *CheckpointReadCloser) Commit() error {
+	if c.state.isClosed() {
+		return ErrClosed
+	}
+	offset := c.Offset()
+	head := min(offset, checkpointHeadSize)
+	return c.store.Save(c.key, Checkpoint{Offset: offset, HeadSize: head, HeadHash: headHash(c.file, head)})
+}
+
+// Close closes the file. It does not commit.
+func (c *CheckpointReadCloser) Close() error {
+	return c.state.close(c.file.Close)
+}
@@ -0,0 +1,237 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// memoryStore is an in-memory CheckpointStore
+type memoryStore struct {
+	checkpoints map[string]Checkpoint
+	loadErr     error
+	saveErr     error
+}
+
+func (m *memoryStore) Load(key string) (Checkpoint, bool, error) {
+	cp, ok := m.checkpoints[key]
+	return cp, ok, m.loadErr
+}
+
+func (m *memoryStore) Save(key string, cp Checkpoint) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.checkpoints[key] = cp
+	return nil
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileCheckpointStore(filepath.Join(dir, "checkpoints.json"))
+
+	if _, ok, err := store.Load("a.log"); ok || err != nil {
+		t.Fatalf("Expected no checkpoint, got %v, %v", ok, err)
+	}
+	first := Checkpoint{Offset: 10, HeadSize: 10, HeadHash: "abc"}
+	second := Checkpoint{Offset: 20}
+	if err := store.Save("a.log", first); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := store.Save("b.log", second); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A new store reads what the first one saved
+	reopened := NewFileCheckpointStore(filepath.Join(dir, "checkpoints.json"))
+	if cp, ok, err := reopened.Load("a.log"); !ok || err != nil || cp != first {
+		t.Errorf("Expected %+v, got %+v, %v, %v", first, cp, ok, err)
+	}
+	if cp, ok, err := reopened.Load("b.log"); !ok || err != nil || cp != second {
+		t.Errorf("Expected %+v, got %+v, %v, %v", second, cp, ok, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "checkpoints.json.tmp")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the temporary file to be renamed, got %v", err)
+	}
+}
+
+func TestFileCheckpointStore_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	_ = os.WriteFile(corrupt, []byte("{not json"), 0o600)
+	store := NewFileCheckpointStore(corrupt)
+	if _, _, err := store.Load("a"); err == nil {
+		t.Error("Expected Load error for corrupt file")
+	}
+	if err := store.Save("a", Checkpoint{}); err == nil {
+		t.Error("Expected Save error for corrupt file")
+	}
+
+	if _, _, err := NewFileCheckpointStore(dir).Load("a"); err == nil {
+		t.Error("Expected Load error for a directory")
+	}
+	if err := NewFileCheckpointStore(filepath.Join(dir, "missing", "cp.json")).Save("a", Checkpoint{}); err == nil {
+		t.Error("Expected Save error for a missing directory")
+	}
+}
+
+func TestOpenCheckpointed_Resume(t *testing.T) {
+	TrackLeaks(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	store := NewFileCheckpointStore(filepath.Join(dir, "checkpoints.json"))
+	_ = os.WriteFile(path, []byte("{\"n\":1}\nnot json\n{\"n\":2}\n{\"n\":3}\n"), 0o600)
+
+	// First run: process two records, commit, then crash after reading one more
+	c, err := OpenCheckpointed(path, store, ValidJSONFilter)
+	if err != nil {
+		t.Fatalf("OpenCheckpointed failed: %v", err)
+	}
+	buf := make([]byte, 64)
+	n, _ := c.Read(buf)
+	if string(buf[:n]) != "{\"n\":1}\n" {
+		t.Fatalf("Unexpected first record %q", string(buf[:n]))
+	}
+	n, _ = c.Read(buf)
+	if string(buf[:n]) != "{\"n\":2}\n" || c.Offset() != 25 {
+		t.Fatalf("Unexpected second record %q at offset %d", string(buf[:n]), c.Offset())
+	}
+	if err := c.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	_, _ = c.Read(buf)
+	_ = c.Close()
+
+	// Second run: the uncommitted record and appended ones are read
+	appendFile(t, path, "{\"n\":4}\n")
+	c, err = OpenCheckpointed(path, store, ValidJSONFilter)
+	if err != nil {
+		t.Fatalf("OpenCheckpointed failed: %v", err)
+	}
+	defer c.Close()
+	if c.StartOffset() != 25 {
+		t.Errorf("Expected to resume at 25, got %d", c.StartOffset())
+	}
+	out, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "{\"n\":3}\n{\"n\":4}\n" || c.Offset() != 41 {
+		t.Errorf("Unexpected output %q at offset %d", string(out), c.Offset())
+	}
+}
+
+func TestOpenCheckpointed_FailedLineNotSkipped(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	store := &memoryStore{checkpoints: map[string]Checkpoint{}}
+	_ = os.WriteFile(path, []byte("ok 1\nfail\nok 2\n"), 0o600)
+
+	filterErr := errors.New("filter error")
+	failing := func(in string) (string, error) {
+		if in == "fail\n" {
+			return "", filterErr
+		}
+		return in, nil
+	}
+
+	// Read on past the failed line, then commit
+	c, err := OpenCheckpointed(path, store, failing)
+	if err != nil {
+		t.Fatalf("OpenCheckpointed failed: %v", err)
+	}
+	buf := make([]byte, 64)
+	for _, expected := range []error{nil, filterErr, nil, io.EOF} {
+		if _, err := c.Read(buf); err != expected {
+			t.Fatalf("Expected %v, got %v", expected, err)
+		}
+	}
+	if err := c.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	_ = c.Close()
+
+	// The restart resumes at the failed line
+	c, err = OpenCheckpointed(path, store, nil)
+	if err != nil {
+		t.Fatalf("OpenCheckpointed failed: %v", err)
+	}
+	defer c.Close()
+	out, _ := io.ReadAll(c)
+	if c.StartOffset() != 5 || string(out) != "fail\nok 2\n" {
+		t.Errorf("Expected to resume at the failed line, got %q from %d", string(out), c.StartOffset())
+	}
+}
+
+func TestOpenCheckpointed_Invalidated(t *testing.T) {
+	data := strings.Repeat("line\n", 300)
+	tests := []struct {
+		name    string
+		replace string
+	}{
+		{"different head", "LINE\n" + data[5:]},
+		{"shorter file", "line\n"},
+		{"change near the end of the hashed head", data[:1000] + "x" + data[1001:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			store := &memoryStore{checkpoints: map[string]Checkpoint{}}
+			_ = os.WriteFile(path, []byte(data), 0o600)
+
+			c, _ := OpenCheckpointed(path, store, nil)
+			_, _ = io.ReadAll(c)
+			_ = c.Commit()
+			_ = c.Close()
+			if cp := store.checkpoints[path]; cp.Offset != int64(len(data)) || cp.HeadSize != 1024 {
+				t.Fatalf("Unexpected checkpoint %+v", cp)
+			}
+
+			_ = os.WriteFile(path, []byte(tt.replace), 0o600)
+			c, _ = OpenCheckpointed(path, store, nil)
+			defer c.Close()
+			if c.StartOffset() != 0 {
+				t.Errorf("Expected to start over, got %d", c.StartOffset())
+			}
+		})
+	}
+}
+
+func TestOpenCheckpointed_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	_ = os.WriteFile(path, []byte("a\n"), 0o600)
+
+	loadErr := errors.New("load error")
+	if _, err := OpenCheckpointed(path, &memoryStore{loadErr: loadErr}, nil); err != loadErr {
+		t.Errorf("Expected %v, got %v", loadErr, err)
+	}
+	store := &memoryStore{checkpoints: map[string]Checkpoint{}}
+	if _, err := OpenCheckpointed(filepath.Join(dir, "missing.log"), store, nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist, got %v", err)
+	}
+
+	saveErr := errors.New("save error")
+	store.saveErr = saveErr
+	c, err := OpenCheckpointed(path, store, nil)
+	if err != nil {
+		t.Fatalf("OpenCheckpointed failed: %v", err)
+	}
+	if err := c.Commit(); err != saveErr {
+		t.Errorf("Expected %v, got %v", saveErr, err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if _, err := c.Read(make([]byte, 1)); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from Read, got %v", err)
+	}
+	if err := c.Commit(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from Commit, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package go_sio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// CSVJSONReader reads CSV or TSV input and emits one compact JSON object per
+// row, keyed by the header row, as newline-delimited JSON. Quoted fields may
+// span lines, which is why this is a reader stage rather than a line filter.
+type CSVJSONReader struct {
+	csv    *csv.Reader
+	header []string
+	buffer bytes.Buffer
+	err    error
+}
+
+// NewCSVJSONReader reads records separated by comma, for example ',' for CSV
+// or '\t' for TSV. A UTF-8 BOM before the header is removed. Rows with a
+// different number of fields than the header fail with a *csv.ParseError.
+// TSV is read with lazy quotes, since TSV exports rarely quote fields and a
+// bare '"', as in 5" screen, is kept as is.
+func NewCSVJSONReader(r io.Reader, comma rune) *CSVJSONReader {
+	if r == nil {
+		return nil
+	}
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cr.LazyQuotes = comma == '\t'
+	return &CSVJSONReader{csv: cr}
+}
+
+func (c *CSVJSONReader) Read(p []byte) (n int, err error) {
+	if c == nil {
+		return 0, ErrNilReader
+	}
+	for c.buffer.Len() == 0 && c.err == nil {
+		record, err := c.csv.Read()
+		if err != nil {
+			c.err = err
+			break
+		}
+		if c.header == nil {
+			record[0] = strings.TrimPrefix(record[0], "\uFEFF")
+			c.header = record
+			continue
+		}
+		fields := make([]jsonField, len(record))
+		for i, value := range record {
+			fields[i] = jsonField{c.header[i], value}
+		}
+		out, _ := marshalJSONObject(fields) // strings always encode
+		c.buffer.WriteString(out)
+		c.buffer.WriteByte('\n')
+	}
+	if c.buffer.Len() > 0 {
+		return c.buffer.Read(p)
+	}
+	return 0, c.err
+}
+
+// NewJSONToCSVFilter returns a StringLineFilter that converts JSON object
+// lines to CSV rows with the given column order, writing a header row before
+// the first row. Missing keys and nulls become empty fields, strings are
+// written as is and other values as compact JSON. Lines that are not JSON
+// objects are dropped. The filter keeps state and is not safe for
+// concurrent use.
+func NewJSONToCSVFilter(columns []string, comma rune) StringLineFilter {
+	headerDone := false
+	return func(in string) (string, error) {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(in), &obj); err != nil || obj == nil {
+			return "", nil
+		}
+
+		var sb strings.Builder
+		w := csv.NewWriter(&sb)
+		w.Comma = comma
+		if !headerDone {
+			if err := w.Write(columns); err != nil {
+				return "", err
+			}
+			headerDone = true
+		}
+		row := make([]string, len(columns))
+		for i, col := range columns {
+			raw := obj[col]
+			switch {
+			case len(raw) == 0 || string(raw) == "null":
+			case raw[0] == '"':
+				_ = json.Unmarshal(raw, &row[i])
+			default:
+				var compact bytes.Buffer
+				_ = json.Compact(&compact, raw)
+				row[i] = compact.String()
+			}
+		}
+		_ = w.Write(row) // same writer settings as the header
+		w.Flush()
+		return sb.String(), nil
+	}
+}
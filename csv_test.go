@@ -0,0 +1,137 @@
+package go_sio
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewCSVJSONReader(t *testing.T) {
+	if cr := NewCSVJSONReader(nil, ','); cr != nil {
+		t.Error("Expected nil CSVJSONReader for nil reader")
+	}
+	var cr *CSVJSONReader
+	if _, err := cr.Read(make([]byte, 1)); err != ErrNilReader {
+		t.Errorf("Expected ErrNilReader, got %v", err)
+	}
+}
+
+func TestCSVJSONReader_Read(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		comma    rune
+		expected string
+	}{
+		{
+			name:  "csv with multiline quoted field",
+			data:  "\uFEFFid,name,note\n1,alice,\"line one\nline two\"\n2,\"bob, jr\",\"say \"\"hi\"\"\"\n",
+			comma: ',',
+			expected: `{"id":"1","name":"alice","note":"line one\nline two"}` + "\n" +
+				`{"id":"2","name":"bob, jr","note":"say \"hi\""}` + "\n",
+		},
+		{
+			name:     "tsv",
+			data:     "a\tb\n1\t<2>\n",
+			comma:    '\t',
+			expected: `{"a":"1","b":"<2>"}` + "\n",
+		},
+		{
+			name:     "tsv with bare quote",
+			data:     "id\titem\n1\t5\" screen\n",
+			comma:    '\t',
+			expected: `{"id":"1","item":"5\" screen"}` + "\n",
+		},
+		{"header only", "a,b\n", ',', ""},
+		{"empty", "", ',', ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := io.ReadAll(NewCSVJSONReader(strings.NewReader(tt.data), tt.comma))
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(out))
+			}
+		})
+	}
+}
+
+func TestCSVJSONReader_SmallBuffer(t *testing.T) {
+	cr := NewCSVJSONReader(strings.NewReader("k\nvalue\n"), ',')
+	var sb strings.Builder
+	buf := make([]byte, 3)
+	for {
+		n, err := cr.Read(buf)
+		sb.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+	if sb.String() != `{"k":"value"}`+"\n" {
+		t.Errorf("Unexpected output %q", sb.String())
+	}
+}
+
+func TestCSVJSONReader_FieldCountError(t *testing.T) {
+	cr := NewCSVJSONReader(strings.NewReader("a,b\n1,2\n3\n4,5\n"), ',')
+	out, err := io.ReadAll(cr)
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) || !errors.Is(err, csv.ErrFieldCount) {
+		t.Fatalf("Expected csv.ErrFieldCount, got %v", err)
+	}
+	if string(out) != `{"a":"1","b":"2"}`+"\n" {
+		t.Errorf("Expected rows before the error, got %q", string(out))
+	}
+	// The error is sticky
+	if _, err := cr.Read(make([]byte, 10)); !errors.Is(err, csv.ErrFieldCount) {
+		t.Errorf("Expected sticky error, got %v", err)
+	}
+}
+
+func TestNewJSONToCSVFilter(t *testing.T) {
+	data := `{"id":1,"name":"alice","tags":["a","b"],"note":"line one` + `\n` + `line two"}` + "\n" +
+		"not json\n" +
+		"null\n" +
+		`{"name":"bob, jr","id":2.5,"extra":true}` + "\n" +
+		`{"id":null,"ok":false}` + "\n"
+
+	filter := NewJSONToCSVFilter([]string{"id", "name", "tags", "note"}, ',')
+	out, err := io.ReadAll(NewStreamReader(strings.NewReader(data), filter))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	expected := "id,name,tags,note\n" +
+		"1,alice,\"[\"\"a\"\",\"\"b\"\"]\",\"line one\nline two\"\n" +
+		"2.5,\"bob, jr\",,\n" +
+		",,,\n"
+	if string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(out))
+	}
+}
+
+func TestNewJSONToCSVFilter_RoundTrip(t *testing.T) {
+	data := "name\tnote\nalice\t\"multi\nline\"\n"
+	ndjson := NewCSVJSONReader(strings.NewReader(data), '\t')
+	out, err := io.ReadAll(NewStreamReader(ndjson, NewJSONToCSVFilter([]string{"name", "note"}, '\t')))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != data {
+		t.Errorf("Expected %q, got %q", data, string(out))
+	}
+}
+
+func TestNewJSONToCSVFilter_InvalidComma(t *testing.T) {
+	filter := NewJSONToCSVFilter([]string{"a"}, '"')
+	if _, err := filter(`{"a":1}`); err == nil {
+		t.Error("Expected error for invalid delimiter")
+	}
+}
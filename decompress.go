@@ -0,0 +1,84 @@
+package go_sio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"os"
+)
+
+// Compression is a compressed stream format recognized by its magic bytes.
+type Compression int
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionZlib
+	CompressionBzip2
+)
+
+// DetectCompression identifies the format of a stream from its first bytes;
+// four bytes are enough. Zlib has no real magic number, so only the headers
+// written by common encoders (a 32 KiB window, no preset dictionary) are
+// recognized.
+func DetectCompression(header []byte) Compression {
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return CompressionGzip
+	case bytes.HasPrefix(header, []byte("BZh")):
+		return CompressionBzip2
+	case len(header) >= 2 && header[0] == 0x78 && header[1]&0x20 == 0 &&
+		(uint16(header[0])<<8|uint16(header[1]))%31 == 0:
+		return CompressionZlib
+	}
+	return CompressionNone
+}
+
+// NewDecompressReadCloser sniffs the magic bytes of rc and returns a
+// ReadCloser that yields the decompressed stream: gzip (all members of a
+// multi-member file), zlib or bzip2. Other input is passed through
+// unchanged. Closing the result closes the decompressor and then rc. On
+// error rc is left open.
+func NewDecompressReadCloser(rc io.ReadCloser) (*ReadCloser, error) {
+	if rc == nil {
+		return nil, ErrNilReader
+	}
+	br := bufio.NewReader(rc)
+	header, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch DetectCompression(header) {
+	case CompressionGzip:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return NewMultiReadCloser(gz, rc, gz), nil
+	case CompressionZlib:
+		zr, _ := zlib.NewReader(br) // the header was checked by DetectCompression
+		return NewMultiReadCloser(zr, rc, zr), nil
+	case CompressionBzip2:
+		return NewMultiReadCloser(bzip2.NewReader(br), rc), nil
+	}
+	return NewMultiReadCloser(br, rc), nil
+}
+
+// OpenDecompressed opens the file at path with NewDecompressReadCloser. The
+// file is closed if the header cannot be read.
+func OpenDecompressed(path string) (*ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := NewDecompressReadCloser(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return rc, nil
+}
@@ -0,0 +1,177 @@
+package go_sio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// bzip2Data is "{\"a\":1}\nnot json\n" compressed with bzip2, which the
+// standard library can only decompress.
+const bzip2Data = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\xf2\xc4\xd2\x13\x00\x00\x07\xd9\x80\x00\x10\x50\x00\x20\x10\x20\x11\x8c\x0a\x20\x00\x31\x00\xd0\x01\x4d\x32\x1e\x53\x7a\xa0\xad\x0d\x61\x5b\x19\x54\x7c\x5d\xc9\x14\xe1\x42\x43\xcb\x13\x48\x4c"
+
+func gzipData(t *testing.T, members ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, m := range members {
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write([]byte(m)); err != nil {
+			t.Fatalf("gzip write failed: %v", err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("gzip close failed: %v", err)
+		}
+	}
+	return buf.String()
+}
+
+func zlibData(t *testing.T, level int, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw, _ := zlib.NewWriterLevel(&buf, level)
+	_, _ = zw.Write([]byte(s))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zlib close failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestDetectCompression(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected Compression
+	}{
+		{"gzip", gzipData(t, "x"), CompressionGzip},
+		{"zlib default", zlibData(t, zlib.DefaultCompression, "x"), CompressionZlib},
+		{"zlib fastest", zlibData(t, zlib.BestSpeed, "x"), CompressionZlib},
+		{"zlib best", zlibData(t, zlib.BestCompression, "x"), CompressionZlib},
+		{"bzip2", bzip2Data, CompressionBzip2},
+		{"plain x", "xyz\n", CompressionNone},
+		{"zlib with dictionary", "\x78\xbb", CompressionNone},
+		{"short", "\x1f", CompressionNone},
+		{"empty", "", CompressionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectCompression([]byte(tt.header)); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewDecompressReadCloser(t *testing.T) {
+	lines := "{\"a\":1}\nnot json\n"
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"gzip", gzipData(t, lines)},
+		{"multi-member gzip", gzipData(t, "{\"a\":1}\n", "not ", "json\n")},
+		{"zlib", zlibData(t, zlib.DefaultCompression, lines)},
+		{"bzip2", bzip2Data},
+		{"plain", lines},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := newMockReadCloser(tt.data)
+			rc, err := NewDecompressReadCloser(inner)
+			if err != nil {
+				t.Fatalf("NewDecompressReadCloser failed: %v", err)
+			}
+			out, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			if string(out) != lines {
+				t.Errorf("Expected %q, got %q", lines, string(out))
+			}
+			if err := rc.Close(); err != nil {
+				t.Errorf("Close failed: %v", err)
+			}
+			if !inner.closed {
+				t.Error("Expected the inner reader to be closed")
+			}
+		})
+	}
+}
+
+func TestNewDecompressReadCloser_Errors(t *testing.T) {
+	if _, err := NewDecompressReadCloser(nil); err != ErrNilReader {
+		t.Errorf("Expected ErrNilReader, got %v", err)
+	}
+
+	inner := newMockReadCloser("\x1f\x8b\x09\x00\x00\x00\x00\x00\x00\x00")
+	if _, err := NewDecompressReadCloser(inner); !errors.Is(err, gzip.ErrHeader) {
+		t.Errorf("Expected gzip.ErrHeader, got %v", err)
+	}
+	if inner.closed {
+		t.Error("Expected the inner reader to stay open on error")
+	}
+
+	readErr := errors.New("read error")
+	failing := NewReadCloser(&failingReader{err: readErr}, closerFunc(func() error { return nil }))
+	defer failing.Close()
+	if _, err := NewDecompressReadCloser(failing); err != readErr {
+		t.Errorf("Expected %v, got %v", readErr, err)
+	}
+}
+
+func TestNewDecompressReadCloser_CloseError(t *testing.T) {
+	closeErr := errors.New("close error")
+	inner := newMockReadCloser(gzipData(t, "x\n"))
+	inner.err = closeErr
+	rc, err := NewDecompressReadCloser(inner)
+	if err != nil {
+		t.Fatalf("NewDecompressReadCloser failed: %v", err)
+	}
+	if err := rc.Close(); !errors.Is(err, closeErr) {
+		t.Errorf("Expected %v, got %v", closeErr, err)
+	}
+	if _, err := rc.Read(make([]byte, 1)); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestOpenDecompressed(t *testing.T) {
+	TrackLeaks(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log.gz")
+	if err := os.WriteFile(path, []byte(gzipData(t, "{\"a\":1}\n", "not json\n")), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	rc, err := OpenDecompressed(path)
+	if err != nil {
+		t.Fatalf("OpenDecompressed failed: %v", err)
+	}
+	jr := NewJSONFilterReadCloser(rc)
+	out, err := io.ReadAll(jr)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "{\"a\":1}\n" {
+		t.Errorf("Unexpected output %q", string(out))
+	}
+	if err := jr.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	if _, err := OpenDecompressed(filepath.Join(dir, "missing.gz")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist, got %v", err)
+	}
+
+	bad := filepath.Join(dir, "bad.gz")
+	_ = os.WriteFile(bad, []byte("\x1f\x8b\x09\x00\x00\x00\x00\x00\x00\x00"), 0o600)
+	if _, err := OpenDecompressed(bad); !errors.Is(err, gzip.ErrHeader) {
+		t.Errorf("Expected gzip.ErrHeader, got %v", err)
+	}
+}
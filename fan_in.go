@@ -0,0 +1,153 @@
+package go_sio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// FanInOption configures a FanInReadCloser.
+type FanInOption func(*FanInReadCloser)
+
+// WithFanInLabels prefixes every line of the i-th input with labels[i], such
+// as "stderr: ". Inputs past the end of labels get no prefix.
+func WithFanInLabels(labels ...string) FanInOption {
+	return func(f *FanInReadCloser) { f.labels = labels }
+}
+
+// FanInReadCloser reads several inputs concurrently and returns their lines
+// in the order they arrive. Lines are never split or interleaved: each Read
+// returns data from whole lines only, and a final line without a newline
+// gets one.
+//
+// The first read error other than io.EOF cancels the other inputs and is
+// returned once the lines received before it have been read. Inputs are
+// cancelled by closing those that are io.Closers; a goroutine blocked on an
+// input that is not a Closer stays blocked until that Read returns.
+type FanInReadCloser struct {
+	inputs   []io.Reader
+	labels   []string
+	lines    chan string
+	done     chan struct{} // closed to cancel the readers
+	closed   chan struct{} // closed by Close
+	cancel   sync.Once
+	failed   sync.Once
+	err      error
+	closeErr error
+	buffer   bytes.Buffer
+	state    closeState
+}
+
+// NewFanInReadCloser starts reading every input. Returns nil when any input
+// is nil.
+func NewFanInReadCloser(inputs []io.Reader, opts ...FanInOption) *FanInReadCloser {
+	f := &FanInReadCloser{
+		inputs: inputs,
+		lines:  make(chan string, len(inputs)),
+		done:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	for _, r := range inputs {
+		if r == nil {
+			return nil
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, r := range inputs {
+		label := ""
+		if i < len(f.labels) {
+			label = f.labels[i]
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.pump(r, label)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(f.lines)
+	}()
+	f.state.track("FanInReadCloser")
+	return f
+}
+
+// Read blocks until a line has arrived, every input has ended, or the
+// FanInReadCloser is closed.
+func (f *FanInReadCloser) Read(p []byte) (n int, err error) {
+	if f.state.isClosed() {
+		return 0, ErrClosed
+	}
+	if f.buffer.Len() == 0 {
+		select {
+		case line, ok := <-f.lines:
+			if !ok {
+				if f.err != nil {
+					return 0, f.err
+				}
+				return 0, io.EOF
+			}
+			f.buffer.WriteString(line)
+		case <-f.closed:
+			return 0, ErrClosed
+		}
+	}
+	return f.buffer.Read(p)
+}
+
+// Close cancels the inputs, closing every one that is an io.Closer, and
+// unblocks a waiting Read. It does not wait for the reading goroutines.
+func (f *FanInReadCloser) Close() error {
+	return f.state.close(func() error {
+		close(f.closed)
+		f.stop()
+		return f.closeErr
+	})
+}
+
+// pump sends the lines of r until it ends, fails or is cancelled.
+func (f *FanInReadCloser) pump(r io.Reader, label string) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			f.failed.Do(func() { f.err = err })
+			f.stop()
+			return
+		}
+		if line != "" {
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			select {
+			case f.lines <- label + line:
+			case <-f.done:
+				return
+			}
+		}
+		if err == io.EOF {
+			return
+		}
+	}
+}
+
+// stop cancels every input once.
+func (f *FanInReadCloser) stop() {
+	f.cancel.Do(func() {
+		close(f.done)
+		var errs []error
+		for _, r := range f.inputs {
+			if c, ok := r.(io.Closer); ok {
+				errs = append(errs, c.Close())
+			}
+		}
+		f.closeErr = errors.Join(errs...)
+	})
+}
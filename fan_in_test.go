@@ -0,0 +1,165 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFanInReadCloser(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputs   []string
+		opts     []FanInOption
+		expected []string
+	}{
+		{
+			name:     "whole lines",
+			inputs:   []string{"a1\na2\n", "b1\nb2", ""},
+			expected: []string{"a1\n", "a2\n", "b1\n", "b2\n"},
+		},
+		{
+			name:     "labels",
+			inputs:   []string{"out\n", "err\n", "plain\n"},
+			opts:     []FanInOption{WithFanInLabels("stdout: ", "stderr: ")},
+			expected: []string{"plain\n", "stderr: err\n", "stdout: out\n"},
+		},
+		{
+			name: "no inputs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inputs []io.Reader
+			for _, in := range tt.inputs {
+				inputs = append(inputs, strings.NewReader(in))
+			}
+			f := NewFanInReadCloser(inputs, tt.opts...)
+			defer f.Close()
+			out, err := io.ReadAll(f)
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			lines := strings.SplitAfter(string(out), "\n")
+			lines = slices.DeleteFunc(lines, func(s string) bool { return s == "" })
+			slices.Sort(lines)
+			if !slices.Equal(lines, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, lines)
+			}
+		})
+	}
+}
+
+func TestFanInReadCloser_ArrivalOrder(t *testing.T) {
+	ra, wa := io.Pipe()
+	rb, wb := io.Pipe()
+	f := NewFanInReadCloser([]io.Reader{ra, rb}, WithFanInLabels("a ", "b "))
+	defer f.Close()
+
+	readLine := func(expected string) {
+		t.Helper()
+		buf := make([]byte, 64)
+		n, err := f.Read(buf)
+		if err != nil || string(buf[:n]) != expected {
+			t.Fatalf("Expected %q, got %q, %v", expected, string(buf[:n]), err)
+		}
+	}
+
+	go func() {
+		_, _ = wb.Write([]byte("fir"))
+		_, _ = wa.Write([]byte("second"))
+		_, _ = wb.Write([]byte("st\n"))
+	}()
+	readLine("b first\n")
+	go func() {
+		_, _ = wa.Write([]byte(" line\nthird"))
+		_ = wa.Close()
+	}()
+	readLine("a second line\n")
+	readLine("a third\n")
+	_ = wb.Close()
+	if _, err := f.Read(make([]byte, 8)); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestFanInReadCloser_SmallReads(t *testing.T) {
+	f := NewFanInReadCloser([]io.Reader{strings.NewReader("a long line\n")})
+	defer f.Close()
+	buf := make([]byte, 4)
+	var sb strings.Builder
+	for {
+		n, err := f.Read(buf)
+		sb.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+	if sb.String() != "a long line\n" {
+		t.Errorf("Expected %q, got %q", "a long line\n", sb.String())
+	}
+}
+
+func TestFanInReadCloser_ErrorCancels(t *testing.T) {
+	readErr := errors.New("read failed")
+	pr, pw := io.Pipe()
+	f := NewFanInReadCloser([]io.Reader{&failingReader{data: "ok\npartial", err: readErr}, pr})
+	defer f.Close()
+
+	out, err := io.ReadAll(f)
+	if !errors.Is(err, readErr) {
+		t.Errorf("Expected %v, got %v", readErr, err)
+	}
+	if string(out) != "ok\n" {
+		t.Errorf("Expected %q, got %q", "ok\n", string(out))
+	}
+	if _, err := pw.Write([]byte("x\n")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Expected the other input to be closed, got %v", err)
+	}
+}
+
+func TestFanInReadCloser_Close(t *testing.T) {
+	closeErr := errors.New("close failed")
+	pr, _ := io.Pipe()
+	mock := newMockReadCloser("")
+	mock.err = closeErr
+	f := NewFanInReadCloser([]io.Reader{pr, mock})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = f.Close()
+	}()
+	if _, err := f.Read(make([]byte, 8)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed from a blocked Read, got %v", err)
+	}
+	if err := f.Close(); !errors.Is(err, closeErr) {
+		t.Errorf("Expected %v, got %v", closeErr, err)
+	}
+	if _, err := f.Read(make([]byte, 8)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestFanInReadCloser_Nil(t *testing.T) {
+	if f := NewFanInReadCloser([]io.Reader{strings.NewReader(""), nil}); f != nil {
+		t.Error("Expected nil for a nil input")
+	}
+}
+
+func TestFanInReadCloser_CloseUnblocksSenders(t *testing.T) {
+	f := NewFanInReadCloser([]io.Reader{strings.NewReader(strings.Repeat("line\n", 10))})
+	time.Sleep(20 * time.Millisecond) // the reader fills the channel and blocks
+	if err := f.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	// The channel is closed once every reader has returned
+	for range f.lines {
+	}
+}
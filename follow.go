@@ -0,0 +1,198 @@
+package go_sio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPollInterval = 250 * time.Millisecond
+	followChunk         = 32 * 1024
+)
+
+// FollowOption configures a FollowReadCloser.
+type FollowOption func(*FollowReadCloser)
+
+// WithPollInterval sets how long Read waits before checking the file again
+// once it has caught up. The default is 250ms.
+func WithPollInterval(d time.Duration) FollowOption {
+	return func(f *FollowReadCloser) { f.interval = d }
+}
+
+// WithFollowFromEnd skips the data already in the file, like tail -f.
+func WithFollowFromEnd() FollowOption {
+	return func(f *FollowReadCloser) { f.fromEnd = true }
+}
+
+// FollowReadCloser reads a growing file and, instead of returning io.EOF at
+// its end, polls for appended data until it is closed. It only returns
+// complete lines, holding back a final line until its newline is written.
+//
+// Rotation is detected on each poll by comparing the file at the path with
+// the open one (os.SameFile) and its size with the read offset:
+//   - rename and create: the old file is read to its end and the new file is
+//     read from the start. A final line without a newline in the old file
+//     will never be completed, so it is emitted with one added.
+//   - copytruncate: the file is read again from the start and a held back
+//     partial line is discarded, since its bytes went to the copy.
+type FollowReadCloser struct {
+	path     string
+	interval time.Duration
+	fromEnd  bool
+	mu       sync.Mutex
+	files    []*os.File  // the file being read, then a rotated-in file
+	info     os.FileInfo // of the newest file
+	offset   int64
+	pending  []byte
+	buffer   bytes.Buffer
+	done     chan struct{}
+	state    closeState
+}
+
+// NewFollowReadCloser opens the file at path for following. It uses polling
+// only, so it works the same on every platform.
+func NewFollowReadCloser(path string, opts ...FollowOption) (*FollowReadCloser, error) {
+	f := &FollowReadCloser{path: path, interval: defaultPollInterval, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(f)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	f.files, f.info = []*os.File{file}, info
+	if f.fromEnd {
+		f.offset = info.Size()
+	}
+	f.state.track("FollowReadCloser")
+	return f, nil
+}
+
+// Read blocks until at least one complete line is available, the file can
+// no longer be read, or the FollowReadCloser is closed.
+func (f *FollowReadCloser) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	chunk := make([]byte, followChunk)
+	for {
+		if n, err := f.fill(p, chunk); n > 0 || err != nil {
+			return n, err
+		}
+		select {
+		case <-f.done:
+			return 0, ErrClosed
+		case <-time.After(f.interval):
+		}
+	}
+}
+
+// Close stops following, unblocks a waiting Read and closes the file.
+func (f *FollowReadCloser) Close() error {
+	return f.state.close(func() error {
+		close(f.done)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var errs []error
+		for _, file := range f.files {
+			errs = append(errs, file.Close())
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// fill reads new data until there are complete lines to return or the file
+// has nothing more for now. Holding the lock keeps Close from closing the
+// file in between.
+func (f *FollowReadCloser) fill(p, chunk []byte) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.state.isClosed() {
+		return 0, ErrClosed
+	}
+	for f.buffer.Len() == 0 {
+		progress, err := f.poll(chunk)
+		if err != nil || !progress {
+			return 0, err
+		}
+	}
+	return f.buffer.Read(p)
+}
+
+// poll reads new data from the current file, or checks for rotation when
+// there is none. It reports whether there may be more to read right away.
+func (f *FollowReadCloser) poll(chunk []byte) (bool, error) {
+	n, err := f.files[0].ReadAt(chunk, f.offset)
+	if n > 0 {
+		f.consume(chunk[:n])
+		return true, nil
+	}
+	if err != io.EOF {
+		return false, err
+	}
+	if len(f.files) > 1 {
+		// The rotated file is drained. Its last line will never be
+		// completed, so terminate it before moving on.
+		if len(f.pending) > 0 {
+			f.buffer.Write(f.pending)
+			f.buffer.WriteByte('\n')
+			f.pending = f.pending[:0]
+		}
+		_ = f.files[0].Close()
+		f.files, f.offset = f.files[1:], 0
+		return true, nil
+	}
+	return f.checkRotation()
+}
+
+// consume appends data to the held back partial line and moves every
+// complete line to the output buffer.
+func (f *FollowReadCloser) consume(data []byte) {
+	f.offset += int64(len(data))
+	f.pending = append(f.pending, data...)
+	if i := bytes.LastIndexByte(f.pending, '\n'); i >= 0 {
+		f.buffer.Write(f.pending[:i+1])
+		f.pending = append(f.pending[:0], f.pending[i+1:]...)
+	}
+}
+
+func (f *FollowReadCloser) checkRotation() (bool, error) {
+	info, err := os.Stat(f.path)
+	if err == nil && os.SameFile(info, f.info) {
+		if info.Size() >= f.offset {
+			return false, nil
+		}
+		// copytruncate
+		f.offset = 0
+		f.pending = f.pending[:0]
+		return true, nil
+	}
+
+	var next *os.File
+	if err == nil {
+		next, err = os.Open(f.path)
+	}
+	if os.IsNotExist(err) {
+		// Renamed away and not created again yet
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Rename and create. Writers may still append to the old file, so it is
+	// read to its end once more before switching.
+	if nextInfo, err := next.Stat(); err == nil {
+		info = nextInfo
+	}
+	f.files, f.info = append(f.files, next), info
+	return true, nil
+}
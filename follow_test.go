@@ -0,0 +1,233 @@
+package go_sio
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFollow reads from f until want has been read or a second has passed.
+func readFollow(t *testing.T, f *FollowReadCloser, want string) {
+	t.Helper()
+	got := make(chan string, 1)
+	go func() {
+		var sb strings.Builder
+		buf := make([]byte, 4)
+		for sb.Len() < len(want) {
+			n, err := f.Read(buf)
+			if err != nil {
+				break
+			}
+			sb.Write(buf[:n])
+		}
+		got <- sb.String()
+	}()
+	select {
+	case s := <-got:
+		if s != want {
+			t.Fatalf("Expected %q, got %q", want, s)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for %q", want)
+	}
+}
+
+// readFollowErr waits for the next Read to fail.
+func readFollowErr(t *testing.T, f *FollowReadCloser) error {
+	t.Helper()
+	errc := make(chan error, 1)
+	go func() {
+		_, err := f.Read(make([]byte, 10))
+		errc <- err
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for Read to fail")
+		return nil
+	}
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(data); err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+}
+
+func newFollow(t *testing.T, path string, opts ...FollowOption) *FollowReadCloser {
+	t.Helper()
+	f, err := NewFollowReadCloser(path, append([]FollowOption{WithPollInterval(5 * time.Millisecond)}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewFollowReadCloser failed: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestNewFollowReadCloser_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFollowReadCloser(filepath.Join(dir, "missing.log")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist, got %v", err)
+	}
+
+	sock := filepath.Join(dir, "sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets not available: %v", err)
+	}
+	defer l.Close()
+	if _, err := NewFollowReadCloser(sock); err == nil {
+		t.Error("Expected error opening a socket")
+	}
+}
+
+func TestFollowReadCloser_Appends(t *testing.T) {
+	TrackLeaks(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "a\nb\npart")
+	f := newFollow(t, path)
+
+	readFollow(t, f, "a\nb\n")
+	appendFile(t, path, "ial")
+	appendFile(t, path, "\nc\n")
+	readFollow(t, f, "partial\nc\n")
+
+	if n, err := f.Read(nil); n != 0 || err != nil {
+		t.Errorf("Expected 0, nil for an empty buffer, got %d, %v", n, err)
+	}
+}
+
+func TestFollowReadCloser_FromEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "old\n")
+	f := newFollow(t, path, WithFollowFromEnd())
+
+	appendFile(t, path, "new\n")
+	readFollow(t, f, "new\n")
+}
+
+func TestFollowReadCloser_RenameAndCreate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "old1\nold-tail")
+	f := newFollow(t, path)
+	readFollow(t, f, "old1\n")
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	// Read polls a missing path until the writer reopens it, after a last
+	// write to the renamed file
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		old, _ := os.OpenFile(path+".1", os.O_APPEND|os.O_WRONLY, 0)
+		_, _ = old.WriteString("-late")
+		_ = old.Close()
+		_ = os.WriteFile(path, []byte("new1\n"), 0o600)
+	}()
+	readFollow(t, f, "old-tail-late\nnew1\n")
+
+	appendFile(t, path, "new2\n")
+	readFollow(t, f, "new2\n")
+}
+
+func TestFollowReadCloser_CopyTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "a\nb\npartial")
+	f := newFollow(t, path)
+	readFollow(t, f, "a\nb\n")
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+	appendFile(t, path, "c\n")
+	readFollow(t, f, "c\n")
+}
+
+func TestFollowReadCloser_RotationErrors(t *testing.T) {
+	t.Run("path no longer a directory", func(t *testing.T) {
+		root := t.TempDir()
+		dir := filepath.Join(root, "logs")
+		_ = os.Mkdir(dir, 0o700)
+		appendFile(t, filepath.Join(dir, "app.log"), "a\n")
+		f := newFollow(t, filepath.Join(dir, "app.log"))
+		readFollow(t, f, "a\n")
+
+		_ = os.Rename(dir, dir+".old")
+		appendFile(t, dir, "not a directory")
+		if err := readFollowErr(t, f); err == nil || err == ErrClosed {
+			t.Errorf("Expected stat error, got %v", err)
+		}
+	})
+
+	t.Run("replaced by a socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		appendFile(t, path, "a\n")
+		f := newFollow(t, path)
+		readFollow(t, f, "a\n")
+
+		_ = os.Rename(path, path+".1")
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			t.Skipf("unix sockets not available: %v", err)
+		}
+		defer l.Close()
+		if err := readFollowErr(t, f); err == nil || err == ErrClosed {
+			t.Errorf("Expected open error, got %v", err)
+		}
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		f := newFollow(t, t.TempDir())
+		if err := readFollowErr(t, f); err == nil || err == ErrClosed {
+			t.Errorf("Expected read error, got %v", err)
+		}
+	})
+}
+
+func TestFollowReadCloser_Close(t *testing.T) {
+	TrackLeaks(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "a\n")
+	f, err := NewFollowReadCloser(path, WithPollInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("NewFollowReadCloser failed: %v", err)
+	}
+	readFollow(t, f, "a\n")
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := f.Read(make([]byte, 10))
+		errc <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	select {
+	case err := <-errc:
+		if err != ErrClosed {
+			t.Errorf("Expected ErrClosed from the waiting Read, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not unblock Read")
+	}
+
+	if _, err := f.Read(make([]byte, 10)); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("Expected second Close to return nil, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package go_sio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// GzipOption configures a GzipWriter.
+type GzipOption func(*GzipWriter)
+
+// WithGzipLevel sets the compression level, from gzip.HuffmanOnly to
+// gzip.BestCompression. The default is gzip.DefaultCompression.
+func WithGzipLevel(level int) GzipOption {
+	return func(g *GzipWriter) { g.level = level }
+}
+
+// WithGzipFlushLines flushes the compressor at the first line boundary after
+// every n complete lines, so an archive that is cut off (for example by a
+// crash) still decodes up to the last flushed line. Flushing costs
+// compression ratio; n <= 0 disables it, which is the default.
+func WithGzipFlushLines(n int) GzipOption {
+	return func(g *GzipWriter) { g.flushLines = n }
+}
+
+// GzipWriter is a gzip-compressing sink for NewTeeReaderCloser and
+// LineWriter. Close writes the gzip trailer but does not close the
+// underlying writer.
+type GzipWriter struct {
+	zw         *gzip.Writer
+	level      int
+	flushLines int
+	lines      int
+	mu         sync.Mutex
+	closed     bool // set under mu, so no Write lands after the trailer
+	state      closeState
+}
+
+func NewGzipWriter(w io.Writer, opts ...GzipOption) (*GzipWriter, error) {
+	if w == nil {
+		return nil, ErrNilWriter
+	}
+	g := &GzipWriter{level: gzip.DefaultCompression}
+	for _, opt := range opts {
+		opt(g)
+	}
+	zw, err := gzip.NewWriterLevel(w, g.level)
+	if err != nil {
+		return nil, err
+	}
+	g.zw = zw
+	g.state.track("GzipWriter")
+	return g, nil
+}
+
+func (g *GzipWriter) Write(p []byte) (n int, err error) {
+	if g == nil {
+		return 0, ErrNilWriter
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return 0, ErrClosed
+	}
+	if g.flushLines <= 0 {
+		return g.zw.Write(p)
+	}
+
+	g.lines += bytes.Count(p, []byte{'\n'})
+	if g.lines < g.flushLines {
+		return g.zw.Write(p)
+	}
+	// Flush after the last complete line and keep the rest buffered
+	i := bytes.LastIndexByte(p, '\n') + 1
+	if n, err = g.zw.Write(p[:i]); err != nil {
+		return n, err
+	}
+	if err = g.zw.Flush(); err != nil {
+		return n, err
+	}
+	g.lines = 0
+	m, err := g.zw.Write(p[i:])
+	return n + m, err
+}
+
+// Flush writes all buffered data as a complete deflate block, so everything
+// written so far can be decoded.
+func (g *GzipWriter) Flush() error {
+	if g == nil {
+		return ErrNilWriter
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return ErrClosed
+	}
+	g.lines = 0
+	return g.zw.Flush()
+}
+
+// Close flushes the compressor and writes the gzip trailer.
+func (g *GzipWriter) Close() error {
+	if g == nil {
+		return ErrNilWriter
+	}
+	return g.state.close(func() error {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.closed = true
+		return g.zw.Close()
+	})
+}
+
+// NewGzipTeeReaderCloser is NewTeeReaderCloser with a GzipWriter in front of
+// w. Closing it closes r and then finishes the gzip stream, so the archive
+// is complete once Close returns; w itself is not closed.
+func NewGzipTeeReaderCloser(r io.ReadCloser, w io.Writer, opts ...GzipOption) (*TeeReaderCloser, error) {
+	if r == nil {
+		return nil, ErrNilReader
+	}
+	g, err := NewGzipWriter(w, opts...)
+	if err != nil {
+		return nil, err
+	}
+	t := &TeeReaderCloser{reader: io.TeeReader(r, g), closer: NewMultiCloser(g, r)}
+	t.state.track("TeeReaderCloser")
+	return t, nil
+}
@@ -0,0 +1,257 @@
+package go_sio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// limitedWriter accepts a number of writes and then fails
+type limitedWriter struct {
+	bytes.Buffer
+	writes int
+	err    error
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.writes == 0 {
+		return 0, l.err
+	}
+	l.writes--
+	return l.Buffer.Write(p)
+}
+
+// gunzip decodes data, returning what could be decoded and the error that
+// stopped decoding.
+func gunzip(data []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	out, err := io.ReadAll(zr)
+	return string(out), err
+}
+
+func TestNewGzipWriter(t *testing.T) {
+	if _, err := NewGzipWriter(nil); err != ErrNilWriter {
+		t.Errorf("Expected ErrNilWriter, got %v", err)
+	}
+	if _, err := NewGzipWriter(io.Discard, WithGzipLevel(42)); err == nil {
+		t.Error("Expected error for invalid level")
+	}
+
+	var g *GzipWriter
+	if _, err := g.Write([]byte("x")); err != ErrNilWriter {
+		t.Errorf("Expected ErrNilWriter from Write, got %v", err)
+	}
+	if err := g.Flush(); err != ErrNilWriter {
+		t.Errorf("Expected ErrNilWriter from Flush, got %v", err)
+	}
+	if err := g.Close(); err != ErrNilWriter {
+		t.Errorf("Expected ErrNilWriter from Close, got %v", err)
+	}
+}
+
+func TestGzipWriter_Levels(t *testing.T) {
+	data := strings.Repeat("GET /index.html 200\n", 100)
+	for _, level := range []int{gzip.HuffmanOnly, gzip.NoCompression, gzip.BestSpeed, gzip.BestCompression} {
+		var buf bytes.Buffer
+		g, err := NewGzipWriter(&buf, WithGzipLevel(level))
+		if err != nil {
+			t.Fatalf("NewGzipWriter(%d) failed: %v", level, err)
+		}
+		_, _ = g.Write([]byte(data))
+		if err := g.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if out, err := gunzip(buf.Bytes()); err != nil || out != data {
+			t.Errorf("Level %d: round trip failed: %v", level, err)
+		}
+	}
+}
+
+func TestGzipWriter_FlushLines(t *testing.T) {
+	TrackLeaks(t)
+	var buf bytes.Buffer
+	g, _ := NewGzipWriter(&buf, WithGzipFlushLines(2))
+
+	steps := []struct {
+		write    string
+		expected string
+	}{
+		{"a\n", ""},
+		{"b\nc", "a\nb\n"},
+		{"c\nd\n", "a\nb\ncc\nd\n"},
+		{"e", "a\nb\ncc\nd\n"},
+	}
+	for _, step := range steps {
+		if n, err := g.Write([]byte(step.write)); err != nil || n != len(step.write) {
+			t.Fatalf("Write(%q) = %d, %v", step.write, n, err)
+		}
+		// A cut-off archive decodes up to the last flushed line
+		out, err := gunzip(buf.Bytes())
+		if out != step.expected || (err != io.ErrUnexpectedEOF && err != io.EOF) {
+			t.Errorf("After %q expected %q, got %q (%v)", step.write, step.expected, out, err)
+		}
+	}
+
+	if err := g.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if out, _ := gunzip(buf.Bytes()); out != "a\nb\ncc\nd\ne" {
+		t.Errorf("Expected everything after Flush, got %q", out)
+	}
+
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if out, err := gunzip(buf.Bytes()); err != nil || out != "a\nb\ncc\nd\ne" {
+		t.Errorf("Expected complete archive, got %q (%v)", out, err)
+	}
+	if err := g.Close(); err != nil {
+		t.Errorf("Expected second Close to return nil, got %v", err)
+	}
+	if _, err := g.Write([]byte("x")); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from Write, got %v", err)
+	}
+	if err := g.Flush(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from Flush, got %v", err)
+	}
+}
+
+func TestGzipWriter_WriteErrors(t *testing.T) {
+	writeErr := errors.New("write error")
+
+	// The header write fails
+	g, _ := NewGzipWriter(&limitedWriter{err: writeErr}, WithGzipFlushLines(1))
+	if _, err := g.Write([]byte("a\n")); err != writeErr {
+		t.Errorf("Expected %v from header write, got %v", writeErr, err)
+	}
+
+	// The line boundary flush fails
+	g, _ = NewGzipWriter(&limitedWriter{writes: 1, err: writeErr}, WithGzipFlushLines(1))
+	if n, err := g.Write([]byte("a\nb")); err != writeErr || n != 2 {
+		t.Errorf("Expected 2, %v from flush, got %d, %v", writeErr, n, err)
+	}
+
+	g, _ = NewGzipWriter(&limitedWriter{writes: 1, err: writeErr})
+	_, _ = g.Write([]byte("a\n"))
+	if err := g.Close(); err != writeErr {
+		t.Errorf("Expected %v from Close, got %v", writeErr, err)
+	}
+}
+
+func TestGzipWriter_CloseRacingWrites(t *testing.T) {
+	for range 20 {
+		var out bytes.Buffer
+		g, _ := NewGzipWriter(&out)
+
+		// Hold the lock so the Write and Flush are past their entry checks
+		// and waiting when Close starts
+		g.mu.Lock()
+		var wg sync.WaitGroup
+		var writeErr, flushErr error
+		wg.Go(func() { _, writeErr = g.Write([]byte("data")) })
+		wg.Go(func() { flushErr = g.Flush() })
+		time.Sleep(time.Millisecond)
+		wg.Go(func() { _ = g.Close() })
+		time.Sleep(time.Millisecond)
+		g.mu.Unlock()
+		wg.Wait()
+
+		if writeErr != nil && writeErr != ErrClosed {
+			t.Fatalf("Expected nil or ErrClosed from Write, got %v", writeErr)
+		}
+		if flushErr != nil && flushErr != ErrClosed {
+			t.Fatalf("Expected nil or ErrClosed from Flush, got %v", flushErr)
+		}
+		got, err := gunzip(out.Bytes())
+		if err != nil {
+			t.Fatalf("Archive does not decode: %v", err)
+		}
+		// Accepted data must be in the archive
+		if writeErr == nil && got != "data" || writeErr != nil && got != "" {
+			t.Fatalf("Write returned %v, archive has %q", writeErr, got)
+		}
+	}
+}
+
+func TestNewGzipTeeReaderCloser(t *testing.T) {
+	TrackLeaks(t)
+	data := "{\"a\":1}\nnot json\n{\"b\":2}\n"
+	inner := newMockReadCloser(data)
+	var archive bytes.Buffer
+
+	tee, err := NewGzipTeeReaderCloser(inner, &archive, WithGzipLevel(gzip.BestSpeed), WithGzipFlushLines(1))
+	if err != nil {
+		t.Fatalf("NewGzipTeeReaderCloser failed: %v", err)
+	}
+	rc := NewJSONFilterReadCloser(tee)
+	out, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "{\"a\":1}\n{\"b\":2}\n" {
+		t.Errorf("Unexpected output %q", string(out))
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !inner.closed {
+		t.Error("Expected the inner reader to be closed")
+	}
+	if got, err := gunzip(archive.Bytes()); err != nil || got != data {
+		t.Errorf("Expected complete archive %q, got %q (%v)", data, got, err)
+	}
+	if _, err := tee.Read(make([]byte, 1)); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestNewGzipTeeReaderCloser_Errors(t *testing.T) {
+	if _, err := NewGzipTeeReaderCloser(nil, io.Discard); err != ErrNilReader {
+		t.Errorf("Expected ErrNilReader, got %v", err)
+	}
+	if _, err := NewGzipTeeReaderCloser(newMockReadCloser(""), nil); err != ErrNilWriter {
+		t.Errorf("Expected ErrNilWriter, got %v", err)
+	}
+
+	// Close reports both the reader and the trailer error
+	readerErr := errors.New("reader close error")
+	writeErr := errors.New("write error")
+	inner := newMockReadCloser("x\n")
+	inner.err = readerErr
+	tee, _ := NewGzipTeeReaderCloser(inner, &limitedWriter{err: writeErr})
+	err := tee.Close()
+	if !errors.Is(err, readerErr) || !errors.Is(err, writeErr) {
+		t.Errorf("Expected both close errors, got %v", err)
+	}
+}
+
+func TestGzipWriter_LineWriter(t *testing.T) {
+	TrackLeaks(t)
+	var archive bytes.Buffer
+	g, _ := NewGzipWriter(&archive, WithGzipFlushLines(1))
+	lw := NewLineWriter(g, func(in string) (string, error) {
+		return strings.ToUpper(in), nil
+	})
+
+	_, _ = io.WriteString(lw, "first\nsec")
+	_, _ = io.WriteString(lw, "ond\npartial")
+	if got, _ := gunzip(archive.Bytes()); got != "FIRST\nSECOND\n" {
+		t.Errorf("Expected complete lines before Close, got %q", got)
+	}
+
+	// Close the line writer first so its partial line reaches the archive
+	if err := NewMultiCloser(g, lw).Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got, err := gunzip(archive.Bytes()); err != nil || got != "FIRST\nSECOND\nPARTIAL" {
+		t.Errorf("Unexpected archive %q (%v)", got, err)
+	}
+}
@@ -0,0 +1,89 @@
+package go_sio
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HookOption attaches a lifecycle callback to a HookReadCloser.
+type HookOption func(*hooks)
+
+type hooks struct {
+	onFirstRead func(elapsed time.Duration)
+	onEOF       func(n int64, elapsed time.Duration)
+	onError     func(err error, n int64, elapsed time.Duration)
+	onClose     func(n int64, elapsed time.Duration, err error)
+}
+
+// OnFirstRead is called once, when the first bytes are read, with the time
+// elapsed since the wrapper was created.
+func OnFirstRead(f func(elapsed time.Duration)) HookOption {
+	return func(h *hooks) { h.onFirstRead = f }
+}
+
+// OnEOF is called once, when the inner reader reports io.EOF, with the total
+// bytes read and the time elapsed since creation.
+func OnEOF(f func(n int64, elapsed time.Duration)) HookOption {
+	return func(h *hooks) { h.onEOF = f }
+}
+
+// OnError is called once, for the first Read error other than io.EOF.
+func OnError(f func(err error, n int64, elapsed time.Duration)) HookOption {
+	return func(h *hooks) { h.onError = f }
+}
+
+// OnClose is called once, after the inner closer has been closed, with the
+// total bytes read, how long the stream was open and the Close result.
+func OnClose(f func(n int64, elapsed time.Duration, err error)) HookOption {
+	return func(h *hooks) { h.onClose = f }
+}
+
+type HookReadCloser struct {
+	rc        io.ReadCloser
+	hooks     hooks
+	start     time.Time
+	n         atomic.Int64
+	firstRead sync.Once
+	eof       sync.Once
+	failed    sync.Once
+	state     closeState
+}
+
+func NewHookReadCloser(rc io.ReadCloser, opts ...HookOption) *HookReadCloser {
+	h := &HookReadCloser{rc: rc, start: time.Now()}
+	for _, opt := range opts {
+		opt(&h.hooks)
+	}
+	h.state.track("HookReadCloser")
+	return h
+}
+
+func (h *HookReadCloser) Read(p []byte) (n int, err error) {
+	if h.state.isClosed() {
+		return 0, ErrClosed
+	}
+	n, err = h.rc.Read(p)
+	total := h.n.Add(int64(n))
+	if n > 0 && h.hooks.onFirstRead != nil {
+		h.firstRead.Do(func() { h.hooks.onFirstRead(time.Since(h.start)) })
+	}
+	switch {
+	case err == io.EOF && h.hooks.onEOF != nil:
+		h.eof.Do(func() { h.hooks.onEOF(total, time.Since(h.start)) })
+	case err != nil && err != io.EOF && h.hooks.onError != nil:
+		h.failed.Do(func() { h.hooks.onError(err, total, time.Since(h.start)) })
+	}
+	return n, err
+}
+
+func (h *HookReadCloser) Close() error {
+	return h.state.close(func() error {
+		err := h.rc.Close()
+		if h.hooks.onClose != nil {
+			h.hooks.onClose(h.n.Load(), time.Since(h.start), err)
+		}
+		return err
+	})
+}
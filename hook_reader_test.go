@@ -0,0 +1,141 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingReader returns data and then a fixed error
+type failingReader struct {
+	data string
+	err  error
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.data == "" {
+		return 0, f.err
+	}
+	n := copy(p, f.data)
+	f.data = f.data[n:]
+	return n, nil
+}
+
+func TestHookReadCloser_Lifecycle(t *testing.T) {
+	var firstReads, eofs, closes int
+	var eofBytes, closeBytes int64
+	var closeErr error
+	expectedErr := errors.New("close error")
+	source := newMockReadCloser("line1\nline2\n")
+	source.err = expectedErr
+
+	h := NewHookReadCloser(source,
+		OnFirstRead(func(elapsed time.Duration) {
+			firstReads++
+			if elapsed < 0 {
+				t.Errorf("Expected non-negative elapsed time, got %v", elapsed)
+			}
+		}),
+		OnEOF(func(n int64, elapsed time.Duration) {
+			eofs++
+			eofBytes = n
+		}),
+		OnError(func(err error, n int64, elapsed time.Duration) {
+			t.Errorf("Unexpected OnError call: %v", err)
+		}),
+		OnClose(func(n int64, elapsed time.Duration, err error) {
+			closes++
+			closeBytes = n
+			closeErr = err
+		}),
+	)
+
+	buf := make([]byte, 4)
+	var got strings.Builder
+	for {
+		n, err := h.Read(buf)
+		got.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+	if got.String() != "line1\nline2\n" {
+		t.Errorf("Expected %q, got %q", "line1\nline2\n", got.String())
+	}
+	// A second EOF must not fire the hook again
+	if _, err := h.Read(buf); err != io.EOF {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := h.Close(); err != expectedErr {
+			t.Errorf("Expected close error %v, got %v", expectedErr, err)
+		}
+	}
+	if _, err := h.Read(buf); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after close, got %v", err)
+	}
+
+	if firstReads != 1 || eofs != 1 || closes != 1 {
+		t.Errorf("Expected each hook once, got firstRead=%d eof=%d close=%d", firstReads, eofs, closes)
+	}
+	if eofBytes != 12 || closeBytes != 12 {
+		t.Errorf("Expected 12 bytes at EOF and close, got %d and %d", eofBytes, closeBytes)
+	}
+	if closeErr != expectedErr {
+		t.Errorf("Expected OnClose to receive %v, got %v", expectedErr, closeErr)
+	}
+}
+
+func TestHookReadCloser_OnError(t *testing.T) {
+	readErr := errors.New("read error")
+	var calls int
+	var gotErr error
+	var gotBytes int64
+	h := NewHookReadCloser(
+		NewReadCloser(&failingReader{data: "abc", err: readErr}, &mockCloser{}),
+		OnError(func(err error, n int64, elapsed time.Duration) {
+			calls++
+			gotErr = err
+			gotBytes = n
+		}),
+	)
+
+	_, err := io.ReadAll(h)
+	if err != readErr {
+		t.Fatalf("Expected %v, got %v", readErr, err)
+	}
+	_, _ = h.Read(make([]byte, 1))
+
+	if calls != 1 {
+		t.Errorf("Expected OnError once, got %d", calls)
+	}
+	if gotErr != readErr || gotBytes != 3 {
+		t.Errorf("Expected (%v, 3), got (%v, %d)", readErr, gotErr, gotBytes)
+	}
+}
+
+func TestHookReadCloser_NoHooks(t *testing.T) {
+	h := NewHookReadCloser(NewReadCloser(&failingReader{data: "abc", err: errors.New("boom")}, &mockCloser{}))
+
+	data, err := io.ReadAll(h)
+	if err == nil {
+		t.Error("Expected read error")
+	}
+	if string(data) != "abc" {
+		t.Errorf("Expected %q, got %q", "abc", string(data))
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	h = NewHookReadCloser(newMockReadCloser("x"))
+	if _, err := io.ReadAll(h); err != nil {
+		t.Errorf("ReadAll failed: %v", err)
+	}
+}
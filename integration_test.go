@@ -2,7 +2,9 @@ package go_sio
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -156,11 +158,19 @@ also not json
 	})
 }
 
-// BenchmarkStreamReader exercises StreamReader in common filter scenarios.
-func BenchmarkStreamReader(b *testing.B) {
+type streamBenchmark struct {
+	name   string
+	data   string
+	filter StringLineFilter
+}
+
+// streamBenchmarks are the filter scenarios shared by the StreamReader and
+// ParallelStreamReader benchmarks.
+func streamBenchmarks() []streamBenchmark {
 	passThroughData := strings.Repeat("test line\n", 1000)
 	dropData := strings.Repeat("keep\nskip\n", 500)           // drops half the lines
 	transformData := strings.Repeat("lowercase line\n", 1000) // uppercases all lines
+	extractData := strings.Repeat(`{"ts":"2024-05-01T14:02:00Z","level":"info","user":"u123","msg":"request done"}`+"\n", 1000)
 
 	dropFilter := func(s string) (string, error) {
 		if strings.HasPrefix(s, "keep") {
@@ -171,18 +181,30 @@ func BenchmarkStreamReader(b *testing.B) {
 	transformFilter := func(s string) (string, error) {
 		return strings.ToUpper(s), nil
 	}
+	userPattern := regexp.MustCompile(`"user":"([^"]*)"`)
+	extractFilter := func(s string) (string, error) { // regex extraction plus a JSON rewrite
+		var record map[string]any
+		if err := json.Unmarshal([]byte(s), &record); err != nil {
+			return "", err
+		}
+		if m := userPattern.FindStringSubmatch(s); m != nil {
+			record["user"] = strings.ToUpper(m[1])
+		}
+		out, err := json.Marshal(record)
+		return string(out) + "\n", err
+	}
 
-	benchmarks := []struct {
-		name   string
-		data   string
-		filter StringLineFilter
-	}{
+	return []streamBenchmark{
 		{"NoFilter", passThroughData, NopFilter},
 		{"FilterDropHalf", dropData, dropFilter},
 		{"FilterTransform", transformData, transformFilter},
+		{"FilterExtractJSON", extractData, extractFilter},
 	}
+}
 
-	for _, bm := range benchmarks {
+// BenchmarkStreamReader exercises StreamReader in common filter scenarios.
+func BenchmarkStreamReader(b *testing.B) {
+	for _, bm := range streamBenchmarks() {
 		b.Run(bm.name, func(b *testing.B) {
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
@@ -194,6 +216,22 @@ func BenchmarkStreamReader(b *testing.B) {
 	}
 }
 
+// BenchmarkParallelStreamReader runs the BenchmarkStreamReader scenarios on
+// 4 workers.
+func BenchmarkParallelStreamReader(b *testing.B) {
+	for _, bm := range streamBenchmarks() {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				reader := strings.NewReader(bm.data)
+				pr := NewParallelStreamReader(reader, bm.filter, 4)
+				_, _ = io.ReadAll(pr)
+				_ = pr.Close()
+			}
+		})
+	}
+}
+
 // BenchmarkJSONFilter measures filtering of mixed JSON/non-JSON lines.
 func BenchmarkJSONFilter(b *testing.B) {
 	data := strings.Repeat(`{"test": "data"}
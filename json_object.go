@@ -0,0 +1,35 @@
+package go_sio
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// jsonField is one member of a JSON object whose key order must be kept.
+type jsonField struct {
+	key   string
+	value any
+}
+
+// marshalJSONObject encodes fields as a compact JSON object in the given
+// order. HTML characters are left unescaped, since the output is log data.
+func marshalJSONObject(fields []jsonField) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		_ = enc.Encode(f.key) // strings always encode
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(f.value); err != nil {
+			return "", err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.String(), nil
+}
@@ -0,0 +1,46 @@
+package go_sio
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestMarshalJSONObject(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []jsonField
+		expected string
+	}{
+		{"empty", nil, `{}`},
+		{
+			name: "keeps order and types",
+			fields: []jsonField{
+				{"z", "last<first>"},
+				{"a", 1},
+				{"m", true},
+				{"raw", json.RawMessage(`[1,2]`)},
+				{"nil", nil},
+			},
+			expected: `{"z":"last<first>","a":1,"m":true,"raw":[1,2],"nil":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := marshalJSONObject(tt.fields)
+			if err != nil {
+				t.Fatalf("marshalJSONObject failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMarshalJSONObject_Error(t *testing.T) {
+	if _, err := marshalJSONObject([]jsonField{{"nan", math.NaN()}}); err == nil {
+		t.Error("Expected error for unsupported value")
+	}
+}
@@ -0,0 +1,97 @@
+package go_sio
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LeakTB is the part of testing.TB used by TrackLeaks.
+type LeakTB interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...any)
+}
+
+type leakRecord struct {
+	id    uint64
+	kind  string
+	stack string
+}
+
+var leaks struct {
+	active atomic.Int32
+	mu     sync.Mutex
+	next   uint64
+	open   map[uint64]*leakRecord
+}
+
+// TrackLeaks records where every closer of this package is created from now
+// until the end of the test, and fails the test with the creation stack of
+// each one that was not closed by then. Tracking is process-wide, so a test
+// running in parallel with other tests may be blamed for their leaks.
+func TrackLeaks(t LeakTB) {
+	t.Helper()
+	leaks.mu.Lock()
+	if leaks.open == nil {
+		leaks.open = make(map[uint64]*leakRecord)
+	}
+	start := leaks.next
+	leaks.active.Add(1)
+	leaks.mu.Unlock()
+
+	t.Cleanup(func() {
+		t.Helper()
+		leaks.mu.Lock()
+		var leaked []*leakRecord
+		for id, rec := range leaks.open {
+			if id >= start {
+				leaked = append(leaked, rec)
+				delete(leaks.open, id)
+			}
+		}
+		leaks.active.Add(-1)
+		leaks.mu.Unlock()
+
+		sort.Slice(leaked, func(i, j int) bool { return leaked[i].id < leaked[j].id })
+		for _, rec := range leaked {
+			t.Errorf("go_sio: %s was not closed, created at:\n%s", rec.kind, rec.stack)
+		}
+	})
+}
+
+func trackLeak(kind string) *leakRecord {
+	if leaks.active.Load() == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var sb strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&sb, "\t%s\n\t\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+
+	leaks.mu.Lock()
+	defer leaks.mu.Unlock()
+	rec := &leakRecord{id: leaks.next, kind: kind, stack: sb.String()}
+	leaks.next++
+	leaks.open[rec.id] = rec
+	return rec
+}
+
+func untrackLeak(rec *leakRecord) {
+	if rec == nil {
+		return
+	}
+	leaks.mu.Lock()
+	defer leaks.mu.Unlock()
+	delete(leaks.open, rec.id)
+}
@@ -0,0 +1,95 @@
+package go_sio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeTB records errors and runs cleanups on demand
+type fakeTB struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestTrackLeaks_ReportsUnclosed(t *testing.T) {
+	tb := &fakeTB{}
+	TrackLeaks(tb)
+
+	leaked := NewJSONFilterReadCloser(io.NopCloser(strings.NewReader("{}\n")))
+	_ = leaked
+	closed := NewTeeReaderCloser(newMockReadCloser("data"), io.Discard)
+	_ = closed.Close()
+	tee := NewTeeReaderCloser(newMockReadCloser("data"), io.Discard)
+	_ = tee
+
+	tb.finish()
+
+	if len(tb.errors) != 2 {
+		t.Fatalf("Expected 2 leak reports, got %d: %v", len(tb.errors), tb.errors)
+	}
+	if !strings.Contains(tb.errors[0], "ReadCloser was not closed") {
+		t.Errorf("Expected ReadCloser leak first, got %q", tb.errors[0])
+	}
+	if !strings.Contains(tb.errors[0], "TestTrackLeaks_ReportsUnclosed") {
+		t.Errorf("Expected creation stack to name the test, got %q", tb.errors[0])
+	}
+	if !strings.Contains(tb.errors[1], "TeeReaderCloser was not closed") {
+		t.Errorf("Expected TeeReaderCloser leak second, got %q", tb.errors[1])
+	}
+}
+
+func TestTrackLeaks_AllClosed(t *testing.T) {
+	tb := &fakeTB{}
+	TrackLeaks(tb)
+
+	rc := NewMultiReadCloser(strings.NewReader("x"), &mockCloser{})
+	h := NewHookReadCloser(rc)
+	_ = h.Close()
+
+	tb.finish()
+
+	if len(tb.errors) != 0 {
+		t.Errorf("Expected no leak reports, got %v", tb.errors)
+	}
+}
+
+func TestTrackLeaks_Inactive(t *testing.T) {
+	rc := NewReadCloser(strings.NewReader("x"), &mockCloser{})
+	if rc.state.leak != nil {
+		t.Error("Expected no leak record without an active tracker")
+	}
+
+	tb := &fakeTB{}
+	TrackLeaks(tb)
+	tb.finish()
+	// Closing a wrapper created before tracking started must be harmless
+	_ = rc.Close()
+
+	if len(tb.errors) != 0 {
+		t.Errorf("Expected no leak reports, got %v", tb.errors)
+	}
+}
+
+func TestTrackLeaks_WithTesting(t *testing.T) {
+	TrackLeaks(t)
+	rc := NewReadCloser(strings.NewReader("x"), &mockCloser{})
+	defer rc.Close()
+}
@@ -0,0 +1,224 @@
+package go_sio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var (
+	ErrLineRange    = errors.New("line range out of bounds")
+	ErrInvalidIndex = errors.New("invalid line index")
+)
+
+// DefaultIndexInterval is the index interval used when none is given.
+const DefaultIndexInterval = 1000
+
+const lineIndexMagic = "SIOX\x01"
+
+// LineIndex is a sparse index of the '\n'-terminated lines of a file. It
+// records the offset of every Interval-th line, so finding line n reads at
+// most Interval-1 lines. A final line without a newline counts as a line.
+type LineIndex struct {
+	Interval int
+	Lines    int64   // number of lines
+	Size     int64   // number of bytes indexed
+	Offsets  []int64 // Offsets[i] is the offset of line i*Interval
+}
+
+// IndexLines reads r to the end and indexes it. An interval of 0 or less
+// means DefaultIndexInterval.
+func IndexLines(r io.Reader, interval int) (*LineIndex, error) {
+	if r == nil {
+		return nil, ErrNilReader
+	}
+	if interval <= 0 {
+		interval = DefaultIndexInterval
+	}
+	ix := &LineIndex{Interval: interval}
+	buf := make([]byte, 64*1024)
+	lineStart := true
+	for {
+		n, err := r.Read(buf)
+		data := buf[:n]
+		for len(data) > 0 {
+			if lineStart {
+				if ix.Lines%int64(interval) == 0 {
+					ix.Offsets = append(ix.Offsets, ix.Size)
+				}
+				ix.Lines++
+			}
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				ix.Size += int64(len(data))
+				lineStart = false
+				break
+			}
+			ix.Size += int64(i + 1)
+			data = data[i+1:]
+			lineStart = true
+		}
+		if err == io.EOF {
+			return ix, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ReadLines returns a StreamReader over lines [from, to) of r, which must be
+// the indexed input. The lines go through f and opts exactly as they would
+// when reading the whole input with NewStreamReader. Line numbers start at
+// 0; to is capped at the number of lines.
+func (ix *LineIndex) ReadLines(r io.ReaderAt, from, to int64, f StringLineFilter, opts ...StreamOption) (*StreamReader, error) {
+	if r == nil {
+		return nil, ErrNilReader
+	}
+	start, skip, count, err := ix.locate(from, to)
+	if err != nil {
+		return nil, err
+	}
+	section := io.NewSectionReader(r, start, math.MaxInt64-start)
+	return NewStreamReader(newLineRangeReader(section, skip, count), f, opts...), nil
+}
+
+// ReadLinesSeeker is ReadLines for an io.ReadSeeker. It seeks rs to the
+// nearest indexed line and reads on from there.
+func (ix *LineIndex) ReadLinesSeeker(rs io.ReadSeeker, from, to int64, f StringLineFilter, opts ...StreamOption) (*StreamReader, error) {
+	if rs == nil {
+		return nil, ErrNilReader
+	}
+	start, skip, count, err := ix.locate(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return NewStreamReader(newLineRangeReader(rs, skip, count), f, opts...), nil
+}
+
+// locate finds the indexed offset at or before line from, the number of
+// lines to skip after it and the number of lines to read.
+func (ix *LineIndex) locate(from, to int64) (start, skip, count int64, err error) {
+	if from < 0 || to < from || from > ix.Lines {
+		return 0, 0, 0, fmt.Errorf("%w: [%d, %d) of %d lines", ErrLineRange, from, to, ix.Lines)
+	}
+	to = min(to, ix.Lines)
+	if from == ix.Lines {
+		return ix.Size, 0, 0, nil
+	}
+	i := from / int64(ix.Interval)
+	return ix.Offsets[i], from - i*int64(ix.Interval), to - from, nil
+}
+
+// MarshalBinary encodes the index compactly, with offsets delta-encoded as
+// varints.
+func (ix *LineIndex) MarshalBinary() ([]byte, error) {
+	buf := []byte(lineIndexMagic)
+	buf = binary.AppendUvarint(buf, uint64(ix.Interval))
+	buf = binary.AppendUvarint(buf, uint64(ix.Lines))
+	buf = binary.AppendUvarint(buf, uint64(ix.Size))
+	buf = binary.AppendUvarint(buf, uint64(len(ix.Offsets)))
+	prev := int64(0)
+	for _, off := range ix.Offsets {
+		buf = binary.AppendUvarint(buf, uint64(off-prev))
+		prev = off
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes an index written by MarshalBinary. Malformed data
+// fails with an error wrapping ErrInvalidIndex.
+func (ix *LineIndex) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(lineIndexMagic)) {
+		return fmt.Errorf("%w: bad header", ErrInvalidIndex)
+	}
+	data = data[len(lineIndexMagic):]
+	next := func() (int64, bool) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 || v > math.MaxInt64 {
+			return 0, false
+		}
+		data = data[n:]
+		return int64(v), true
+	}
+
+	interval, ok1 := next()
+	lines, ok2 := next()
+	size, ok3 := next()
+	count, ok4 := next()
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return fmt.Errorf("%w: bad header field", ErrInvalidIndex)
+	}
+	if interval <= 0 || interval > math.MaxInt32 || count != lines/interval+min(lines%interval, 1) {
+		return fmt.Errorf("%w: %d offsets for %d lines at interval %d", ErrInvalidIndex, count, lines, interval)
+	}
+	offsets := make([]int64, 0, min(count, int64(len(data))))
+	prev := int64(0)
+	for range count {
+		delta, ok := next()
+		if !ok || delta > size-prev {
+			return fmt.Errorf("%w: bad offset", ErrInvalidIndex)
+		}
+		prev += delta
+		offsets = append(offsets, prev)
+	}
+	if len(data) > 0 {
+		return fmt.Errorf("%w: trailing data", ErrInvalidIndex)
+	}
+	*ix = LineIndex{Interval: int(interval), Lines: lines, Size: size, Offsets: offsets}
+	return nil
+}
+
+// lineRangeReader skips whole lines and then passes a number of lines
+// through, so that the StreamReader above it sees the same lines as one
+// reading the whole input.
+type lineRangeReader struct {
+	r         *bufio.Reader
+	skip      int64
+	remaining int64
+	pending   []byte
+	err       error
+}
+
+func newLineRangeReader(r io.Reader, skip, count int64) *lineRangeReader {
+	l := &lineRangeReader{r: bufio.NewReader(r), skip: skip, remaining: count}
+	if count == 0 {
+		l.err = io.EOF
+	}
+	return l
+}
+
+func (l *lineRangeReader) Read(p []byte) (int, error) {
+	for len(l.pending) == 0 {
+		if l.err != nil {
+			return 0, l.err
+		}
+		line, err := l.r.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull {
+			l.err = err
+		}
+		complete := err == nil
+		if l.skip > 0 {
+			if complete {
+				l.skip--
+			}
+			continue
+		}
+		if complete {
+			if l.remaining--; l.remaining == 0 {
+				l.err = io.EOF
+			}
+		}
+		l.pending = line
+	}
+	n := copy(p, l.pending)
+	l.pending = l.pending[n:]
+	return n, nil
+}
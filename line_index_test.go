@@ -0,0 +1,257 @@
+package go_sio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// numberedLines returns n lines "line 0\n", "line 1\n", ...
+func numberedLines(n int) string {
+	var sb strings.Builder
+	for i := range n {
+		sb.WriteString("line " + strconv.Itoa(i) + "\n")
+	}
+	return sb.String()
+}
+
+func TestIndexLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		interval int
+		expected LineIndex
+	}{
+		{"empty", "", 2, LineIndex{Interval: 2}},
+		{"one line", "a\n", 2, LineIndex{Interval: 2, Lines: 1, Size: 2, Offsets: []int64{0}}},
+		{"unterminated", "a\nbb\nc", 2, LineIndex{Interval: 2, Lines: 3, Size: 6, Offsets: []int64{0, 5}}},
+		{"empty lines", "\n\n\n\n", 2, LineIndex{Interval: 2, Lines: 4, Size: 4, Offsets: []int64{0, 2}}},
+		{"default interval", "a\n", 0, LineIndex{Interval: DefaultIndexInterval, Lines: 1, Size: 2, Offsets: []int64{0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// One byte reads split lines across reads
+			ix, err := IndexLines(iotest.OneByteReader(strings.NewReader(tt.data)), tt.interval)
+			if err != nil {
+				t.Fatalf("IndexLines failed: %v", err)
+			}
+			if ix.Interval != tt.expected.Interval || ix.Lines != tt.expected.Lines || ix.Size != tt.expected.Size ||
+				len(ix.Offsets) != len(tt.expected.Offsets) {
+				t.Fatalf("Expected %+v, got %+v", tt.expected, *ix)
+			}
+			for i := range ix.Offsets {
+				if ix.Offsets[i] != tt.expected.Offsets[i] {
+					t.Errorf("Expected %+v, got %+v", tt.expected, *ix)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexLines_Errors(t *testing.T) {
+	if _, err := IndexLines(nil, 10); err != ErrNilReader {
+		t.Errorf("Expected ErrNilReader, got %v", err)
+	}
+	readErr := errors.New("read error")
+	if _, err := IndexLines(&failingReader{data: "a\n", err: readErr}, 10); err != readErr {
+		t.Errorf("Expected %v, got %v", readErr, err)
+	}
+}
+
+func TestLineIndex_ReadLines(t *testing.T) {
+	data := numberedLines(25) + "tail"
+	ix, _ := IndexLines(strings.NewReader(data), 4)
+	all := strings.SplitAfter(data, "\n")
+
+	tests := []struct {
+		name     string
+		from, to int64
+	}{
+		{"first lines", 0, 3},
+		{"on interval", 8, 12},
+		{"inside interval", 9, 14},
+		{"single line", 13, 14},
+		{"empty range", 5, 5},
+		{"to end", 22, 26},
+		{"capped", 24, 100},
+		{"at end", 26, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := strings.Join(all[min(tt.from, 26):min(tt.to, 26)], "")
+
+			sr, err := ix.ReadLines(strings.NewReader(data), tt.from, tt.to, nil)
+			if err != nil {
+				t.Fatalf("ReadLines failed: %v", err)
+			}
+			out, err := io.ReadAll(sr)
+			if err != nil || string(out) != expected {
+				t.Errorf("ReadLines: expected %q, got %q (%v)", expected, string(out), err)
+			}
+
+			sr, err = ix.ReadLinesSeeker(strings.NewReader(data), tt.from, tt.to, nil)
+			if err != nil {
+				t.Fatalf("ReadLinesSeeker failed: %v", err)
+			}
+			out, err = io.ReadAll(sr)
+			if err != nil || string(out) != expected {
+				t.Errorf("ReadLinesSeeker: expected %q, got %q (%v)", expected, string(out), err)
+			}
+		})
+	}
+}
+
+func TestLineIndex_ReadLinesFilter(t *testing.T) {
+	data := "{\"a\":1}\nskip\n{\"b\":2}\n" + strings.Repeat("x", 5000) + "\n{\"c\":3}\n{\"d\":4}\n"
+	ix, _ := IndexLines(strings.NewReader(data), 2)
+
+	// Long lines are read through bufio in pieces and filtered whole
+	sr, err := ix.ReadLines(strings.NewReader(data), 1, 5, ValidJSONFilter)
+	if err != nil {
+		t.Fatalf("ReadLines failed: %v", err)
+	}
+	out, err := io.ReadAll(sr)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "{\"b\":2}\n{\"c\":3}\n" {
+		t.Errorf("Unexpected output %q", string(out))
+	}
+
+	// A long skipped line
+	sr, _ = ix.ReadLines(strings.NewReader(data), 4, 5, nil)
+	if out, _ := io.ReadAll(sr); string(out) != "{\"c\":3}\n" {
+		t.Errorf("Unexpected output %q", string(out))
+	}
+}
+
+// failingSeeker fails every Seek
+type failingSeeker struct {
+	io.Reader
+	err error
+}
+
+func (f *failingSeeker) Seek(int64, int) (int64, error) {
+	return 0, f.err
+}
+
+func TestLineIndex_ReadLinesErrors(t *testing.T) {
+	data := numberedLines(10)
+	ix, _ := IndexLines(strings.NewReader(data), 3)
+
+	for _, r := range [][2]int64{{-1, 2}, {3, 2}, {11, 12}} {
+		if _, err := ix.ReadLines(strings.NewReader(data), r[0], r[1], nil); !errors.Is(err, ErrLineRange) {
+			t.Errorf("ReadLines(%d, %d): expected ErrLineRange, got %v", r[0], r[1], err)
+		}
+		if _, err := ix.ReadLinesSeeker(strings.NewReader(data), r[0], r[1], nil); !errors.Is(err, ErrLineRange) {
+			t.Errorf("ReadLinesSeeker(%d, %d): expected ErrLineRange, got %v", r[0], r[1], err)
+		}
+	}
+	if _, err := ix.ReadLines(nil, 0, 1, nil); err != ErrNilReader {
+		t.Errorf("Expected ErrNilReader, got %v", err)
+	}
+	if _, err := ix.ReadLinesSeeker(nil, 0, 1, nil); err != ErrNilReader {
+		t.Errorf("Expected ErrNilReader, got %v", err)
+	}
+	seekErr := errors.New("seek error")
+	if _, err := ix.ReadLinesSeeker(&failingSeeker{err: seekErr}, 0, 1, nil); err != seekErr {
+		t.Errorf("Expected %v, got %v", seekErr, err)
+	}
+
+	// Input shorter than the index
+	sr, _ := ix.ReadLines(strings.NewReader(data[:20]), 1, 9, nil)
+	if out, err := io.ReadAll(sr); err != nil || string(out) != data[7:20] {
+		t.Errorf("Expected truncated output, got %q (%v)", string(out), err)
+	}
+	sr, _ = ix.ReadLines(strings.NewReader(data[:5]), 1, 9, nil)
+	if out, err := io.ReadAll(sr); err != nil || len(out) != 0 {
+		t.Errorf("Expected no output, got %q (%v)", string(out), err)
+	}
+
+	// Read errors are passed on
+	readErr := errors.New("read error")
+	sr, _ = ix.ReadLinesSeeker(&failingSeeker{Reader: &failingReader{data: "line 0\nli", err: readErr}}, 0, 5, nil)
+	if out, err := io.ReadAll(sr); err != readErr || string(out) != "line 0\nli" {
+		t.Errorf("Expected %v after the data, got %q (%v)", readErr, string(out), err)
+	}
+}
+
+func TestLineIndex_MarshalBinary(t *testing.T) {
+	data := numberedLines(1000)
+	ix, _ := IndexLines(strings.NewReader(data), 7)
+	encoded, err := ix.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	var decoded LineIndex
+	if err := decoded.UnmarshalBinary(encoded); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if decoded.Interval != 7 || decoded.Lines != 1000 || decoded.Size != int64(len(data)) || len(decoded.Offsets) != len(ix.Offsets) {
+		t.Fatalf("Unexpected index %+v", decoded)
+	}
+	for i := range ix.Offsets {
+		if decoded.Offsets[i] != ix.Offsets[i] {
+			t.Fatalf("Offset %d: expected %d, got %d", i, ix.Offsets[i], decoded.Offsets[i])
+		}
+	}
+	sr, _ := decoded.ReadLines(strings.NewReader(data), 500, 501, nil)
+	if out, _ := io.ReadAll(sr); string(out) != "line 500\n" {
+		t.Errorf("Unexpected line %q", string(out))
+	}
+
+	var empty LineIndex
+	encoded, _ = (&LineIndex{Interval: 5}).MarshalBinary()
+	if err := empty.UnmarshalBinary(encoded); err != nil || empty.Lines != 0 || len(empty.Offsets) != 0 {
+		t.Errorf("Unexpected empty index %+v (%v)", empty, err)
+	}
+}
+
+func TestLineIndex_UnmarshalBinaryErrors(t *testing.T) {
+	header := func(fields ...uint64) []byte {
+		buf := []byte(lineIndexMagic)
+		for _, f := range fields {
+			buf = binary.AppendUvarint(buf, f)
+		}
+		return buf
+	}
+	valid, _ := (&LineIndex{Interval: 2, Lines: 3, Size: 10, Offsets: []int64{0, 6}}).MarshalBinary()
+
+	tests := []struct {
+		name string
+		data []byte
+		msg  string
+	}{
+		{"bad magic", []byte("JSON{}"), "bad header"},
+		{"truncated header", header(2, 3), "bad header field"},
+		{"overflowing field", header(2, math.MaxUint64, 10, 2), "bad header field"},
+		{"zero interval", header(0, 0, 0, 0), "0 offsets for 0 lines at interval 0"},
+		{"huge interval", header(math.MaxInt32+1, 1, 1, 1), "at interval"},
+		{"wrong count", header(2, 3, 10, 1), "1 offsets for 3 lines"},
+		{"missing offsets", header(2, 3, 10, 2, 0), "bad offset"},
+		{"offset past size", header(2, 3, 10, 2, 0, 11), "bad offset"},
+		{"trailing data", append(bytes.Clone(valid), 0), "trailing data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ix LineIndex
+			err := ix.UnmarshalBinary(tt.data)
+			if !errors.Is(err, ErrInvalidIndex) {
+				t.Fatalf("Expected ErrInvalidIndex, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expected error to contain %q, got %q", tt.msg, err.Error())
+			}
+		})
+	}
+}
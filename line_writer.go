@@ -0,0 +1,119 @@
+package go_sio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+)
+
+var ErrNilWriter = errors.New("writer is nil")
+
+// LineWriter is the write-side counterpart of StreamReader. It buffers
+// partial writes until a newline, runs the filter once per complete line
+// (newline included) and writes the result to the underlying writer. Flush
+// and Close pass a trailing partial line through the filter without a
+// newline. Close does not close the underlying writer.
+type LineWriter struct {
+	w      io.Writer
+	filter StringLineFilter
+	mu     sync.Mutex
+	buf    []byte
+	err    error
+	closed bool // set under mu, so no Write lands after the final flush
+	state  closeState
+}
+
+func NewLineWriter(w io.Writer, f StringLineFilter) *LineWriter {
+	if w == nil {
+		return nil
+	}
+	if f == nil {
+		f = NopFilter
+	}
+	lw := &LineWriter{w: w, filter: f}
+	lw.state.track("LineWriter")
+	return lw
+}
+
+func (lw *LineWriter) Write(p []byte) (n int, err error) {
+	if lw == nil {
+		return 0, ErrNilWriter
+	}
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.closed {
+		return 0, ErrClosed
+	}
+	if lw.err != nil {
+		return 0, lw.err
+	}
+
+	buffered := len(lw.buf)
+	lw.buf = append(lw.buf, p...)
+	consumed := 0
+	for lw.err == nil {
+		i := bytes.IndexByte(lw.buf[consumed:], '\n')
+		if i < 0 {
+			break
+		}
+		line := lw.buf[consumed : consumed+i+1]
+		consumed += i + 1
+		lw.err = lw.emit(string(line))
+	}
+	lw.buf = append(lw.buf[:0], lw.buf[consumed:]...)
+	if lw.err == nil && len(lw.buf) >= bufio.MaxScanTokenSize {
+		lw.err = bufio.ErrTooLong
+	}
+
+	if lw.err != nil {
+		return max(consumed-buffered, 0), lw.err
+	}
+	return len(p), nil
+}
+
+// Flush passes a buffered partial line through the filter and writes it out.
+func (lw *LineWriter) Flush() error {
+	if lw == nil {
+		return ErrNilWriter
+	}
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.closed {
+		return ErrClosed
+	}
+	return lw.flush()
+}
+
+func (lw *LineWriter) Close() error {
+	if lw == nil {
+		return ErrNilWriter
+	}
+	return lw.state.close(func() error {
+		lw.mu.Lock()
+		defer lw.mu.Unlock()
+		lw.closed = true
+		return lw.flush()
+	})
+}
+
+// flush is called with lw.mu held.
+func (lw *LineWriter) flush() error {
+	if lw.err != nil || len(lw.buf) == 0 {
+		return lw.err
+	}
+	line := string(lw.buf)
+	lw.buf = lw.buf[:0]
+	lw.err = lw.emit(line)
+	return lw.err
+}
+
+func (lw *LineWriter) emit(line string) error {
+	out, err := lw.filter(line)
+	if err != nil || out == "" {
+		return err
+	}
+	_, err = io.WriteString(lw.w, out)
+	return err
+}
@@ -0,0 +1,222 @@
+package go_sio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingWriter fails every write with a fixed error
+type failingWriter struct {
+	err error
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	return 0, f.err
+}
+
+func TestNewLineWriter(t *testing.T) {
+	if lw := NewLineWriter(nil, NopFilter); lw != nil {
+		t.Error("Expected nil LineWriter for nil writer")
+	}
+	var out bytes.Buffer
+	lw := NewLineWriter(&out, nil)
+	if lw == nil {
+		t.Fatal("Expected non-nil LineWriter")
+	}
+	if _, err := lw.Write([]byte("a\nb")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := lw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if out.String() != "a\nb" {
+		t.Errorf("Expected NopFilter pass-through %q, got %q", "a\nb", out.String())
+	}
+}
+
+func TestLineWriter_NilReceiver(t *testing.T) {
+	var lw *LineWriter
+	if _, err := lw.Write([]byte("x")); err != ErrNilWriter {
+		t.Errorf("Write: expected ErrNilWriter, got %v", err)
+	}
+	if err := lw.Flush(); err != ErrNilWriter {
+		t.Errorf("Flush: expected ErrNilWriter, got %v", err)
+	}
+	if err := lw.Close(); err != ErrNilWriter {
+		t.Errorf("Close: expected ErrNilWriter, got %v", err)
+	}
+}
+
+func TestLineWriter_PartialWrites(t *testing.T) {
+	var out bytes.Buffer
+	var lines []string
+	lw := NewLineWriter(&out, func(line string) (string, error) {
+		lines = append(lines, line)
+		if strings.Contains(line, "skip") {
+			return "", nil
+		}
+		return strings.ToUpper(line), nil
+	})
+
+	for _, chunk := range []string{"ke", "ep\nsk", "ip\n", "\nlast", " line"} {
+		n, err := lw.Write([]byte(chunk))
+		if err != nil {
+			t.Fatalf("Write(%q) failed: %v", chunk, err)
+		}
+		if n != len(chunk) {
+			t.Errorf("Write(%q): expected %d bytes, got %d", chunk, len(chunk), n)
+		}
+	}
+	if out.String() != "KEEP\n\n" {
+		t.Errorf("Expected %q before flush, got %q", "KEEP\n\n", out.String())
+	}
+
+	if err := lw.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := lw.Flush(); err != nil {
+		t.Fatalf("Second Flush failed: %v", err)
+	}
+	expectedLines := []string{"keep\n", "skip\n", "\n", "last line"}
+	if strings.Join(lines, "|") != strings.Join(expectedLines, "|") {
+		t.Errorf("Expected filter input %q, got %q", expectedLines, lines)
+	}
+	if out.String() != "KEEP\n\nLAST LINE" {
+		t.Errorf("Expected %q, got %q", "KEEP\n\nLAST LINE", out.String())
+	}
+}
+
+func TestLineWriter_Close(t *testing.T) {
+	var out bytes.Buffer
+	lw := NewLineWriter(&out, NopFilter)
+	_, _ = lw.Write([]byte("tail"))
+
+	for i := 0; i < 2; i++ {
+		if err := lw.Close(); err != nil {
+			t.Errorf("Close #%d failed: %v", i+1, err)
+		}
+	}
+	if out.String() != "tail" {
+		t.Errorf("Expected trailing line to be flushed, got %q", out.String())
+	}
+	if _, err := lw.Write([]byte("x")); err != ErrClosed {
+		t.Errorf("Write: expected ErrClosed, got %v", err)
+	}
+	if err := lw.Flush(); err != ErrClosed {
+		t.Errorf("Flush: expected ErrClosed, got %v", err)
+	}
+}
+
+func TestLineWriter_CloseRacingWrites(t *testing.T) {
+	for range 20 {
+		var out bytes.Buffer
+		lw := NewLineWriter(&out, nil)
+
+		// Hold the lock so the Write is past its entry checks and waiting
+		// when Close starts
+		lw.mu.Lock()
+		var wg sync.WaitGroup
+		var writeErr error
+		wg.Go(func() { _, writeErr = lw.Write([]byte("partial")) })
+		time.Sleep(time.Millisecond)
+		wg.Go(func() { _ = lw.Close() })
+		time.Sleep(time.Millisecond)
+		lw.mu.Unlock()
+		wg.Wait()
+
+		// An accepted partial line must reach the final flush
+		if writeErr == nil && out.String() != "partial" {
+			t.Fatalf("Write accepted data that Close did not flush, got %q", out.String())
+		}
+		if writeErr != nil && (writeErr != ErrClosed || out.Len() != 0) {
+			t.Fatalf("Expected ErrClosed and no output, got %v, %q", writeErr, out.String())
+		}
+	}
+}
+
+func TestLineWriter_FilterError(t *testing.T) {
+	expectedErr := errors.New("filter error")
+	var out bytes.Buffer
+	lw := NewLineWriter(&out, func(line string) (string, error) {
+		if strings.HasPrefix(line, "bad") {
+			return "", expectedErr
+		}
+		return line, nil
+	})
+
+	_, _ = lw.Write([]byte("go"))
+	n, err := lw.Write([]byte("od\nbad\nnever\n"))
+	if err != expectedErr {
+		t.Fatalf("Expected %v, got %v", expectedErr, err)
+	}
+	if n != len("od\nbad\n") {
+		t.Errorf("Expected %d bytes consumed, got %d", len("od\nbad\n"), n)
+	}
+	if out.String() != "good\n" {
+		t.Errorf("Expected %q, got %q", "good\n", out.String())
+	}
+
+	// The error is sticky
+	if _, err := lw.Write([]byte("more\n")); err != expectedErr {
+		t.Errorf("Expected sticky %v, got %v", expectedErr, err)
+	}
+	if err := lw.Close(); err != expectedErr {
+		t.Errorf("Expected Close to report %v, got %v", expectedErr, err)
+	}
+}
+
+func TestLineWriter_WriteError(t *testing.T) {
+	expectedErr := errors.New("write error")
+	lw := NewLineWriter(&failingWriter{err: expectedErr}, NopFilter)
+
+	if _, err := lw.Write([]byte("partial")); err != nil {
+		t.Fatalf("Buffered write failed: %v", err)
+	}
+	if err := lw.Flush(); err != expectedErr {
+		t.Errorf("Expected %v, got %v", expectedErr, err)
+	}
+}
+
+func TestLineWriter_TooLong(t *testing.T) {
+	var out bytes.Buffer
+	lw := NewLineWriter(&out, NopFilter)
+
+	n, err := lw.Write([]byte(strings.Repeat("x", bufio.MaxScanTokenSize)))
+	if err != bufio.ErrTooLong {
+		t.Errorf("Expected bufio.ErrTooLong, got %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected 0 bytes, got %d", n)
+	}
+}
+
+func TestLineWriter_MatchesStreamReader(t *testing.T) {
+	data := "INFO: start\nERROR: failed\n\nWARN: disk\nDEBUG: tail"
+	filter := func(line string) (string, error) {
+		if strings.HasPrefix(line, "ERROR") || strings.HasPrefix(line, "WARN") {
+			return strings.ToLower(line), nil
+		}
+		return "", nil
+	}
+
+	var fromReader bytes.Buffer
+	if _, err := fromReader.ReadFrom(NewStreamReader(strings.NewReader(data), filter)); err != nil {
+		t.Fatalf("StreamReader failed: %v", err)
+	}
+
+	var fromWriter bytes.Buffer
+	lw := NewLineWriter(&fromWriter, filter)
+	for i := 0; i < len(data); i += 3 {
+		_, _ = lw.Write([]byte(data[i:min(i+3, len(data))]))
+	}
+	_ = lw.Close()
+
+	if fromWriter.String() != fromReader.String() {
+		t.Errorf("Expected writer output %q to match reader output %q", fromWriter.String(), fromReader.String())
+	}
+}
@@ -0,0 +1,205 @@
+package go_sio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidLogfmt = errors.New("invalid logfmt")
+
+	// LogfmtToJSONFilter converts logfmt lines to compact JSON objects. Lines
+	// that are already valid JSON pass through unchanged; blank lines and
+	// lines that are not valid logfmt are dropped. A line needs at least one
+	// key=value pair to count as logfmt, so plain text made only of bare keys
+	// is dropped too.
+	LogfmtToJSONFilter StringLineFilter = logfmtToJSON
+
+	// JSONToLogfmtFilter converts JSON object lines to logfmt, keeping key
+	// order. Other lines pass through unchanged.
+	JSONToLogfmtFilter StringLineFilter = jsonToLogfmt
+)
+
+// LogfmtField is one key/value pair of a logfmt line. Bare is set for keys
+// that appear without '=', such as "debug" in "level=info debug".
+type LogfmtField struct {
+	Key   string
+	Value string
+	Bare  bool
+}
+
+// ParseLogfmt parses a logfmt line into its fields, in order. Values may be
+// double-quoted, in which case Go string escapes such as \" and \n apply. A
+// trailing line terminator is ignored.
+func ParseLogfmt(line string) ([]LogfmtField, error) {
+	line, _ = cutLineEnd(line)
+	var fields []LogfmtField
+	i := 0
+	for {
+		for i < len(line) && line[i] <= ' ' {
+			i++
+		}
+		if i == len(line) {
+			return fields, nil
+		}
+
+		start := i
+		for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		if i == start || (i < len(line) && line[i] == '"') {
+			return nil, logfmtError(line, i)
+		}
+		field := LogfmtField{Key: line[start:i]}
+		if i == len(line) || line[i] != '=' {
+			field.Bare = true
+			fields = append(fields, field)
+			continue
+		}
+
+		i++
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("%w: unterminated quote at offset %d", ErrInvalidLogfmt, i)
+			}
+			value, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: bad quoted value at offset %d", ErrInvalidLogfmt, i)
+			}
+			field.Value = value
+			i = end + 1
+			if i < len(line) && line[i] > ' ' {
+				return nil, logfmtError(line, i)
+			}
+		} else {
+			start = i
+			for i < len(line) && line[i] > ' ' {
+				if line[i] == '"' {
+					return nil, logfmtError(line, i)
+				}
+				i++
+			}
+			field.Value = line[start:i]
+		}
+		fields = append(fields, field)
+	}
+}
+
+func logfmtError(line string, i int) error {
+	return fmt.Errorf("%w: unexpected %q at offset %d", ErrInvalidLogfmt, line[i], i)
+}
+
+// FormatLogfmt renders fields as a logfmt line without a terminator. Values
+// with spaces, '=', quotes, backslashes or non-printable characters are
+// quoted, and characters that are not allowed in keys are replaced with '_'.
+func FormatLogfmt(fields []LogfmtField) string {
+	var sb strings.Builder
+	for i, f := range fields {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(logfmtKey(f.Key))
+		if f.Bare {
+			continue
+		}
+		sb.WriteByte('=')
+		if logfmtNeedsQuote(f.Value) {
+			sb.WriteString(strconv.Quote(f.Value))
+		} else {
+			sb.WriteString(f.Value)
+		}
+	}
+	return sb.String()
+}
+
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func logfmtNeedsQuote(value string) bool {
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || !strconv.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func logfmtToJSON(in string) (string, error) {
+	line, end := cutLineEnd(in)
+	if strings.TrimSpace(line) == "" {
+		return "", nil
+	}
+	if json.Valid([]byte(line)) {
+		return in, nil
+	}
+	fields, err := ParseLogfmt(line)
+	if err != nil || !slices.ContainsFunc(fields, func(f LogfmtField) bool { return !f.Bare }) {
+		return "", nil
+	}
+
+	var members []jsonField
+	index := make(map[string]int, len(fields))
+	for _, f := range fields {
+		var value any = f.Value
+		if f.Bare {
+			value = true
+		}
+		if i, ok := index[f.Key]; ok {
+			members[i].value = value
+			continue
+		}
+		index[f.Key] = len(members)
+		members = append(members, jsonField{key: f.Key, value: value})
+	}
+	out, _ := marshalJSONObject(members) // strings and booleans always encode
+	return out + end, nil
+}
+
+func jsonToLogfmt(in string) (string, error) {
+	line, end := cutLineEnd(in)
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") || !json.Valid([]byte(trimmed)) {
+		return in, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	_, _ = dec.Token() // opening brace, already validated
+	var fields []LogfmtField
+	for dec.More() {
+		key, _ := dec.Token()
+		var raw json.RawMessage
+		_ = dec.Decode(&raw)
+		value := string(raw)
+		if raw[0] == '"' {
+			_ = json.Unmarshal(raw, &value)
+		} else if raw[0] == '{' || raw[0] == '[' {
+			var compact bytes.Buffer
+			_ = json.Compact(&compact, raw)
+			value = compact.String()
+		}
+		fields = append(fields, LogfmtField{Key: key.(string), Value: value})
+	}
+	return FormatLogfmt(fields) + end, nil
+}
@@ -0,0 +1,213 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected []LogfmtField
+	}{
+		{"empty", "", nil},
+		{"blank with newline", "  \t\n", nil},
+		{
+			name: "simple pairs",
+			line: "level=info msg=\"user logged in\" user_id=123\n",
+			expected: []LogfmtField{
+				{Key: "level", Value: "info"},
+				{Key: "msg", Value: "user logged in"},
+				{Key: "user_id", Value: "123"},
+			},
+		},
+		{
+			name: "bare keys and empty values",
+			line: "debug a= b=\"\" trailing",
+			expected: []LogfmtField{
+				{Key: "debug", Bare: true},
+				{Key: "a", Value: ""},
+				{Key: "b", Value: ""},
+				{Key: "trailing", Bare: true},
+			},
+		},
+		{
+			name: "escapes in quoted value",
+			line: `err="say \"hi\"\n\tbye\\" path=/a=b`,
+			expected: []LogfmtField{
+				{Key: "err", Value: "say \"hi\"\n\tbye\\"},
+				{Key: "path", Value: "/a=b"},
+			},
+		},
+		{
+			name:     "crlf terminator",
+			line:     "k=v\r\n",
+			expected: []LogfmtField{{Key: "k", Value: "v"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLogfmt(tt.line)
+			if err != nil {
+				t.Fatalf("ParseLogfmt failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseLogfmt_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		msg  string
+	}{
+		{"missing key", "=value", `unexpected '=' at offset 0`},
+		{"quote in key", `ke"y=v`, `unexpected '"' at offset 2`},
+		{"quote starts key", `"key"=v`, `unexpected '"' at offset 0`},
+		{"unterminated quote", `msg="open`, "unterminated quote at offset 4"},
+		{"escaped closing quote", `msg="open\"`, "unterminated quote at offset 4"},
+		{"bad escape", `msg="\q"`, "bad quoted value at offset 4"},
+		{"text after quote", `msg="a"b`, `unexpected 'b' at offset 7`},
+		{"quote in bare value", `msg=a"b"`, `unexpected '"' at offset 5`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLogfmt(tt.line)
+			if !errors.Is(err, ErrInvalidLogfmt) {
+				t.Fatalf("Expected ErrInvalidLogfmt, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expected error to contain %q, got %q", tt.msg, err.Error())
+			}
+		})
+	}
+}
+
+func TestFormatLogfmt(t *testing.T) {
+	fields := []LogfmtField{
+		{Key: "level", Value: "info"},
+		{Key: "msg", Value: "user logged in"},
+		{Key: "debug", Bare: true},
+		{Key: "empty", Value: ""},
+		{Key: "bad key=\"", Value: `a=b`},
+		{Key: "", Value: "x"},
+		{Key: "path", Value: `C:\tmp`},
+		{Key: "ctl", Value: "bell\a"},
+		{Key: "utf8", Value: "héllo"},
+	}
+	expected := `level=info msg="user logged in" debug empty= bad_key__="a=b" _=x path="C:\\tmp" ctl="bell\a" utf8=héllo`
+	if got := FormatLogfmt(fields); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	// Formatting then parsing round-trips values
+	parsed, err := ParseLogfmt(expected)
+	if err != nil {
+		t.Fatalf("ParseLogfmt failed: %v", err)
+	}
+	if parsed[1].Value != "user logged in" || parsed[6].Value != `C:\tmp` || parsed[7].Value != "bell\a" {
+		t.Errorf("Round trip changed values: %+v", parsed)
+	}
+}
+
+func TestLogfmtToJSONFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{"logfmt", "level=info msg=\"user logged in\" user_id=123\n", `{"level":"info","msg":"user logged in","user_id":"123"}` + "\n"},
+		{"bare key", "ready level=info", `{"ready":true,"level":"info"}`},
+		{"only bare keys dropped", "user logged in\n", ""},
+		{"plain text dropped", "ERROR: connection failed (timeout)\n", ""},
+		{"duplicate key keeps position", "a=1 b=2 a=3\r\n", `{"a":"3","b":"2"}` + "\r\n"},
+		{"json passes through", `{"already": "json"}` + "\n", `{"already": "json"}` + "\n"},
+		{"blank dropped", "   \n", ""},
+		{"invalid dropped", "msg=\"open\n", ""},
+		{"html not escaped", "url=<a&b>", `{"url":"<a&b>"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LogfmtToJSONFilter(tt.in)
+			if err != nil {
+				t.Fatalf("Filter failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestJSONToLogfmtFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{
+			name:     "object keeps key order",
+			in:       `{"level":"info","msg":"user logged in","user_id":123,"ok":true,"none":null}` + "\n",
+			expected: `level=info msg="user logged in" user_id=123 ok=true none=null` + "\n",
+		},
+		{
+			name:     "nested values are compacted",
+			in:       `{"tags": ["a", "b"], "meta": {"k": 1}}`,
+			expected: `tags="[\"a\",\"b\"]" meta="{\"k\":1}"`,
+		},
+		{"empty object", "{}\n", "\n"},
+		{"array passes through", "[1,2]\n", "[1,2]\n"},
+		{"logfmt passes through", "level=info\n", "level=info\n"},
+		{"invalid json passes through", "{broken\n", "{broken\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONToLogfmtFilter(tt.in)
+			if err != nil {
+				t.Fatalf("Filter failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLogfmt_MixedStream(t *testing.T) {
+	data := "level=info msg=start\n" +
+		`{"level":"warn","msg":"disk"}` + "\n" +
+		"plain text \"broken\n" +
+		"starting worker pool\n" +
+		"level=error msg=\"it failed\"\n"
+
+	out, err := io.ReadAll(NewStreamReader(strings.NewReader(data), LogfmtToJSONFilter))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	expected := `{"level":"info","msg":"start"}` + "\n" +
+		`{"level":"warn","msg":"disk"}` + "\n" +
+		`{"level":"error","msg":"it failed"}` + "\n"
+	if string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(out))
+	}
+
+	back, err := io.ReadAll(NewStreamReader(strings.NewReader(expected), JSONToLogfmtFilter))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	expectedBack := "level=info msg=start\nlevel=warn msg=disk\nlevel=error msg=\"it failed\"\n"
+	if string(back) != expectedBack {
+		t.Errorf("Expected %q, got %q", expectedBack, string(back))
+	}
+}
@@ -0,0 +1,228 @@
+package go_sio
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// MergeOption configures a MergeReadCloser.
+type MergeOption func(*MergeReadCloser)
+
+// WithMergeLabels prefixes every line of the i-th input with labels[i], such
+// as "pod-a | ". Inputs past the end of labels get no prefix.
+func WithMergeLabels(labels ...string) MergeOption {
+	return func(m *MergeReadCloser) { m.labels = labels }
+}
+
+// WithMergeLookahead keeps up to n records of each input buffered and sorted,
+// so a record can overtake up to n-1 records read before it from the same
+// input. The default of 1 is a plain merge of inputs that are each in order.
+func WithMergeLookahead(n int) MergeOption {
+	return func(m *MergeReadCloser) { m.lookahead = max(n, 1) }
+}
+
+// MergeReadCloser merges line streams that are each in time order into one
+// stream in time order. Lines are kept whole: a line is never split and
+// lines without a timestamp, such as stack trace continuations, stay with
+// the timestamped line before them as one record. Records at the same time
+// come out in input order. Lines without a timestamp at the start of an
+// input come out first.
+type MergeReadCloser struct {
+	timestamp TimestampFunc
+	inputs    []io.Reader
+	labels    []string
+	lookahead int
+	sources   []*mergeSource
+	queue     mergeQueue
+	seq       uint64
+	buffer    bytes.Buffer
+	err       error
+	state     closeState
+}
+
+// NewMergeReadCloser merges inputs by the timestamps read with ts. Inputs are
+// read as records are needed. Close closes every input that is an
+// io.Closer. Returns nil when ts or any input is nil.
+func NewMergeReadCloser(ts TimestampFunc, inputs []io.Reader, opts ...MergeOption) *MergeReadCloser {
+	if ts == nil {
+		return nil
+	}
+	m := &MergeReadCloser{timestamp: ts, inputs: inputs, lookahead: 1}
+	for _, opt := range opts {
+		opt(m)
+	}
+	for i, r := range inputs {
+		if r == nil {
+			return nil
+		}
+		src := &mergeSource{br: bufio.NewReader(r), index: i}
+		if i < len(m.labels) {
+			src.label = m.labels[i]
+		}
+		m.sources = append(m.sources, src)
+	}
+	m.state.track("MergeReadCloser")
+	return m
+}
+
+func (m *MergeReadCloser) Read(p []byte) (n int, err error) {
+	if m.state.isClosed() {
+		return 0, ErrClosed
+	}
+	if m.seq == 0 && m.err == nil {
+		for _, src := range m.sources {
+			m.refill(src)
+		}
+	}
+	for m.buffer.Len() == 0 && m.err == nil {
+		if m.queue.Len() == 0 {
+			m.err = io.EOF
+			break
+		}
+		rec := heap.Pop(&m.queue).(mergeRecord)
+		m.buffer.WriteString(rec.data)
+		rec.src.buffered--
+		m.refill(rec.src)
+	}
+	if m.buffer.Len() > 0 {
+		return m.buffer.Read(p)
+	}
+	return 0, m.err
+}
+
+// Close closes every input that is an io.Closer, even if some fail.
+func (m *MergeReadCloser) Close() error {
+	return m.state.close(func() error {
+		var errs []error
+		for _, r := range m.inputs {
+			if c, ok := r.(io.Closer); ok {
+				errs = append(errs, c.Close())
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// refill queues records of src until it has lookahead of them queued or is
+// drained. A read error stops the merge.
+func (m *MergeReadCloser) refill(src *mergeSource) {
+	for m.err == nil && !src.drained() && src.buffered < m.lookahead {
+		data, ts, err := src.record(m.timestamp)
+		if err != nil {
+			m.err = err
+			return
+		}
+		if data == "" {
+			return
+		}
+		m.seq++
+		heap.Push(&m.queue, mergeRecord{data: data, ts: ts, src: src, seq: m.seq})
+		src.buffered++
+	}
+}
+
+// mergeSource reads one input record by record. next holds the line that
+// starts the next record once it has been read.
+type mergeSource struct {
+	br       *bufio.Reader
+	index    int
+	label    string
+	next     string
+	nextTS   time.Time
+	buffered int
+	done     bool
+}
+
+// drained reports whether every record of the input has been returned.
+func (s *mergeSource) drained() bool {
+	return s.done && s.next == ""
+}
+
+// record returns the next record of the input, with a newline added to a
+// final line without one so it cannot run into a line of another input. An
+// empty record means the input is drained.
+func (s *mergeSource) record(timestamp TimestampFunc) (string, time.Time, error) {
+	if s.next == "" {
+		line, err := s.line()
+		if line == "" {
+			return "", time.Time{}, err
+		}
+		s.next, s.nextTS = line, time.Time{}
+		if ts, ok := timestamp(line); ok {
+			s.nextTS = ts
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(s.label)
+	sb.WriteString(s.next)
+	ts := s.nextTS
+	s.next = ""
+	for {
+		line, err := s.line()
+		if line == "" {
+			return sb.String(), ts, err
+		}
+		if next, ok := timestamp(line); ok {
+			s.next, s.nextTS = line, next
+			return sb.String(), ts, nil
+		}
+		sb.WriteString(s.label)
+		sb.WriteString(line)
+	}
+}
+
+// line reads one line, newline terminated. At the end of the input it
+// returns "" and marks the source done.
+func (s *mergeSource) line() (string, error) {
+	line, err := s.br.ReadString('\n')
+	if err == io.EOF {
+		s.done = true
+		err = nil
+		if line != "" {
+			line += "\n"
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return line, nil
+}
+
+type mergeRecord struct {
+	data string
+	ts   time.Time
+	src  *mergeSource
+	seq  uint64
+}
+
+// mergeQueue is a min-heap of records by time, then input, then read order.
+type mergeQueue []mergeRecord
+
+func (q mergeQueue) Len() int { return len(q) }
+
+func (q mergeQueue) Less(i, j int) bool {
+	if c := q[i].ts.Compare(q[j].ts); c != 0 {
+		return c < 0
+	}
+	if q[i].src.index != q[j].src.index {
+		return q[i].src.index < q[j].src.index
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q mergeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *mergeQueue) Push(x any) { *q = append(*q, x.(mergeRecord)) }
+
+func (q *mergeQueue) Pop() any {
+	old := *q
+	rec := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return rec
+}
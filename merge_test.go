@@ -0,0 +1,166 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMergeReadCloser(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputs   []string
+		opts     []MergeOption
+		expected string
+	}{
+		{
+			name:     "interleaved",
+			inputs:   []string{"1 a\n4 a\n5 a\n", "2 b\n3 b\n6 b\n"},
+			expected: "1 a\n2 b\n3 b\n4 a\n5 a\n6 b\n",
+		},
+		{
+			name:     "continuation lines stay with their record",
+			inputs:   []string{"1 a\n\tat a.frame\n3 a\n", "2 b\n\tat b.frame\n\tat b.caller\n"},
+			expected: "1 a\n\tat a.frame\n2 b\n\tat b.frame\n\tat b.caller\n3 a\n",
+		},
+		{
+			name:     "ties in input order",
+			inputs:   []string{"1 a\n1 a2\n", "1 b\n", "0 c\n1 c\n"},
+			expected: "0 c\n1 a\n1 a2\n1 b\n1 c\n",
+		},
+		{
+			name:     "leading untimed lines first",
+			inputs:   []string{"1 a\n", "header\n2 b\n"},
+			expected: "header\n1 a\n2 b\n",
+		},
+		{
+			name:     "missing final newline",
+			inputs:   []string{"1 a\n3 a", "2 b\n\tat b.frame"},
+			expected: "1 a\n2 b\n\tat b.frame\n3 a\n",
+		},
+		{
+			name:     "empty inputs",
+			inputs:   []string{"", "1 b\n", ""},
+			expected: "1 b\n",
+		},
+		{
+			name:     "no inputs",
+			expected: "",
+		},
+		{
+			name:     "labels",
+			inputs:   []string{"1 a\n\tat a.frame\n3 a\n", "2 b\n", "4 c\n"},
+			opts:     []MergeOption{WithMergeLabels("pod-a | ", "pod-b | ")},
+			expected: "pod-a | 1 a\npod-a | \tat a.frame\npod-b | 2 b\npod-a | 3 a\n4 c\n",
+		},
+		{
+			name:     "out of order without lookahead",
+			inputs:   []string{"1 a\n3 a\n2 a\n", "2 b\n"},
+			expected: "1 a\n2 b\n3 a\n2 a\n",
+		},
+		{
+			name:     "lookahead",
+			inputs:   []string{"1 a\n3 a\n2 a\n5 a\n4 a\n", "2 b\n4 b\n"},
+			opts:     []MergeOption{WithMergeLookahead(2)},
+			expected: "1 a\n2 a\n2 b\n3 a\n4 a\n4 b\n5 a\n",
+		},
+		{
+			name:     "lookahead below one",
+			inputs:   []string{"2 a\n1 a\n"},
+			opts:     []MergeOption{WithMergeLookahead(0)},
+			expected: "2 a\n1 a\n",
+		},
+		{
+			name:     "lookahead keeps read order of ties",
+			inputs:   []string{"1 a\n1 a2\n0 a\n"},
+			opts:     []MergeOption{WithMergeLookahead(3)},
+			expected: "0 a\n1 a\n1 a2\n",
+		},
+		{
+			name:     "lookahead with empty input",
+			inputs:   []string{"2 a\n1 a\n", ""},
+			opts:     []MergeOption{WithMergeLookahead(2)},
+			expected: "1 a\n2 a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inputs []io.Reader
+			for _, in := range tt.inputs {
+				inputs = append(inputs, strings.NewReader(in))
+			}
+			m := NewMergeReadCloser(EpochSecondsTimestamp, inputs, tt.opts...)
+			defer m.Close()
+			out, err := io.ReadAll(m)
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(out))
+			}
+		})
+	}
+}
+
+func TestMergeReadCloser_Nil(t *testing.T) {
+	if m := NewMergeReadCloser(nil, []io.Reader{strings.NewReader("")}); m != nil {
+		t.Error("Expected nil for a nil TimestampFunc")
+	}
+	if m := NewMergeReadCloser(EpochSecondsTimestamp, []io.Reader{strings.NewReader(""), nil}); m != nil {
+		t.Error("Expected nil for a nil input")
+	}
+}
+
+func TestMergeReadCloser_ReadError(t *testing.T) {
+	readErr := errors.New("read failed")
+	tests := []struct {
+		name   string
+		failed io.Reader
+	}{
+		{"first line", &failingReader{data: "", err: readErr}},
+		{"continuation line", &failingReader{data: "2 b\n\tat", err: readErr}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMergeReadCloser(EpochSecondsTimestamp, []io.Reader{strings.NewReader("1 a\n"), tt.failed})
+			defer m.Close()
+			out, err := io.ReadAll(m)
+			if !errors.Is(err, readErr) {
+				t.Errorf("Expected %v, got %v", readErr, err)
+			}
+			if len(out) != 0 {
+				t.Errorf("Expected no output, got %q", string(out))
+			}
+			if _, err := m.Read(make([]byte, 8)); !errors.Is(err, readErr) {
+				t.Errorf("Expected the error to stick, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMergeReadCloser_Close(t *testing.T) {
+	closeErr := errors.New("close failed")
+	a, b := newMockReadCloser("1 a\n"), newMockReadCloser("2 b\n")
+	b.err = closeErr
+	m := NewMergeReadCloser(EpochSecondsTimestamp, []io.Reader{a, strings.NewReader("3 c\n"), b})
+
+	buf := make([]byte, 2)
+	if n, err := m.Read(buf); n != 2 || err != nil {
+		t.Fatalf("Expected 2 bytes, got %d, %v", n, err)
+	}
+	if err := m.Close(); !errors.Is(err, closeErr) {
+		t.Errorf("Expected %v, got %v", closeErr, err)
+	}
+	if !a.closed || !b.closed {
+		t.Errorf("Expected every input closed, got %v, %v", a.closed, b.closed)
+	}
+	if _, err := m.Read(buf); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if err := m.Close(); !errors.Is(err, closeErr) {
+		t.Errorf("Expected the same error from a second Close, got %v", err)
+	}
+}
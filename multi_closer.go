@@ -0,0 +1,39 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+)
+
+// MultiCloser closes a stack of closers in reverse order, so closers listed
+// innermost first (file, decompressor, wrappers) are torn down outermost
+// first. Every closer is closed even if some fail.
+type MultiCloser struct {
+	closers []io.Closer
+	state   closeState
+}
+
+func NewMultiCloser(closers ...io.Closer) *MultiCloser {
+	m := &MultiCloser{closers: closers}
+	m.state.track("MultiCloser")
+	return m
+}
+
+func (m *MultiCloser) Close() error {
+	return m.state.close(func() error {
+		var errs []error
+		for i := len(m.closers) - 1; i >= 0; i-- {
+			if m.closers[i] == nil {
+				continue
+			}
+			if err := m.closers[i].Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+func NewMultiReadCloser(r io.Reader, closers ...io.Closer) *ReadCloser {
+	return NewReadCloser(r, NewMultiCloser(closers...))
+}
@@ -0,0 +1,96 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// orderCloser records the order in which closers are closed
+type orderCloser struct {
+	name  string
+	order *[]string
+	err   error
+}
+
+func (o *orderCloser) Close() error {
+	*o.order = append(*o.order, o.name)
+	return o.err
+}
+
+func TestMultiCloser_ReverseOrder(t *testing.T) {
+	var order []string
+	mc := NewMultiCloser(
+		&orderCloser{name: "file", order: &order},
+		&orderCloser{name: "gzip", order: &order},
+		&orderCloser{name: "tee", order: &order},
+	)
+
+	if err := mc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	expected := "tee,gzip,file"
+	if got := strings.Join(order, ","); got != expected {
+		t.Errorf("Expected close order %q, got %q", expected, got)
+	}
+}
+
+func TestMultiCloser_JoinsErrors(t *testing.T) {
+	var order []string
+	errFile := errors.New("file close error")
+	errTee := errors.New("tee close error")
+	mc := NewMultiCloser(
+		&orderCloser{name: "file", order: &order, err: errFile},
+		nil,
+		&orderCloser{name: "gzip", order: &order},
+		&orderCloser{name: "tee", order: &order, err: errTee},
+	)
+
+	err := mc.Close()
+	if !errors.Is(err, errFile) || !errors.Is(err, errTee) {
+		t.Errorf("Expected joined errors, got %v", err)
+	}
+	if len(order) != 3 {
+		t.Errorf("Expected all 3 closers to be closed, got %v", order)
+	}
+
+	// Later closes return the first result without closing again
+	if again := mc.Close(); again != err {
+		t.Errorf("Expected repeated Close to return %v, got %v", err, again)
+	}
+	if len(order) != 3 {
+		t.Errorf("Expected closers to be closed once, got %v", order)
+	}
+}
+
+func TestMultiCloser_Empty(t *testing.T) {
+	if err := NewMultiCloser().Close(); err != nil {
+		t.Errorf("Expected nil error, got %v", err)
+	}
+}
+
+func TestNewMultiReadCloser(t *testing.T) {
+	var order []string
+	file := &orderCloser{name: "file", order: &order}
+	decoder := &orderCloser{name: "decoder", order: &order}
+	rc := NewMultiReadCloser(strings.NewReader("line\n"), file, decoder)
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(data) != "line\n" {
+		t.Errorf("Expected %q, got %q", "line\n", string(data))
+	}
+
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := strings.Join(order, ","); got != "decoder,file" {
+		t.Errorf("Expected close order %q, got %q", "decoder,file", got)
+	}
+	if _, err := rc.Read(make([]byte, 1)); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after close, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package go_sio
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// Lines are handed to the workers in batches of up to this many lines or
+// bytes, so the channel overhead is paid per batch rather than per line.
+const (
+	parallelBatchLines = 128
+	parallelBatchBytes = 32 * 1024
+)
+
+// ParallelStreamReader is a StreamReader that runs its filter on several
+// goroutines. Lines are split at '\n' as by NewStreamReader, filtered in
+// batches by the workers, and their output is put back in input order.
+//
+// The filter must be safe for concurrent use, so filters that keep state
+// between lines, such as NewTimeWindowFilter with UntimedAttach, cannot be
+// used. Batching pays off for CPU-heavy filters over data that is readily
+// available; output for input that trickles in lags by up to a batch.
+type ParallelStreamReader struct {
+	filter  StringLineFilter
+	order   chan *parallelBatch // batches in input order, bounding those in flight
+	jobs    chan *parallelBatch
+	done    chan struct{}
+	stopped sync.Once
+	buffer  bytes.Buffer
+	err     error
+	state   closeState
+}
+
+type parallelBatch struct {
+	lines  []string
+	err    error // read error after the lines
+	result chan parallelResult
+}
+
+type parallelResult struct {
+	out []byte
+	err error
+}
+
+// NewParallelStreamReader filters the lines of r with f on workers
+// goroutines; 0 or less means GOMAXPROCS. At most 2*workers batches are in
+// flight, so memory stays bounded however far the workers get ahead of the
+// caller. A nil f passes lines through. Returns nil when r is nil.
+//
+// Output is the same as NewStreamReader(r, f) would give. The first error,
+// by line order, from f or from reading r is returned after the output of
+// the lines before it, and stops the workers.
+func NewParallelStreamReader(r io.Reader, f StringLineFilter, workers int) *ParallelStreamReader {
+	if r == nil {
+		return nil
+	}
+	if f == nil {
+		f = NopFilter
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	p := &ParallelStreamReader{
+		filter: f,
+		order:  make(chan *parallelBatch, 2*workers),
+		jobs:   make(chan *parallelBatch, workers),
+		done:   make(chan struct{}),
+	}
+	go p.scan(r)
+	for range workers {
+		go p.work()
+	}
+	p.state.track("ParallelStreamReader")
+	return p
+}
+
+func (p *ParallelStreamReader) Read(b []byte) (n int, err error) {
+	if p.state.isClosed() {
+		return 0, ErrClosed
+	}
+	for p.buffer.Len() == 0 && p.err == nil {
+		var batch *parallelBatch
+		select {
+		case batch = <-p.order:
+		case <-p.done:
+			return 0, ErrClosed
+		}
+		if batch == nil {
+			p.err = io.EOF
+			break
+		}
+		select {
+		case res := <-batch.result:
+			p.buffer.Write(res.out)
+			if res.err != nil {
+				p.err = res.err
+				p.stop()
+			}
+		case <-p.done:
+			return 0, ErrClosed
+		}
+	}
+	if p.buffer.Len() > 0 {
+		return p.buffer.Read(b)
+	}
+	return 0, p.err
+}
+
+// Close stops the workers and unblocks a waiting Read. It does not close the
+// underlying reader; a goroutine blocked reading it stays blocked until that
+// Read returns.
+func (p *ParallelStreamReader) Close() error {
+	return p.state.close(func() error {
+		p.stop()
+		return nil
+	})
+}
+
+func (p *ParallelStreamReader) stop() {
+	p.stopped.Do(func() { close(p.done) })
+}
+
+// scan splits r into batches and hands each to Read, through order, and to
+// the workers, through jobs.
+func (p *ParallelStreamReader) scan(r io.Reader) {
+	defer close(p.jobs)
+	defer close(p.order)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Split(split)
+	batch, size := &parallelBatch{}, 0
+	send := func() bool {
+		batch.result = make(chan parallelResult, 1)
+		for _, ch := range []chan *parallelBatch{p.order, p.jobs} {
+			select {
+			case ch <- batch:
+			case <-p.done:
+				return false
+			}
+		}
+		batch, size = &parallelBatch{}, 0
+		return true
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		batch.lines = append(batch.lines, line)
+		size += len(line)
+		if len(batch.lines) >= parallelBatchLines || size >= parallelBatchBytes {
+			if !send() {
+				return
+			}
+		}
+	}
+	if batch.err = scanner.Err(); len(batch.lines) > 0 || batch.err != nil {
+		send()
+	}
+}
+
+// work filters batches until there are no more or the reader is stopped.
+func (p *ParallelStreamReader) work() {
+	for {
+		select {
+		case batch, ok := <-p.jobs:
+			if !ok {
+				return
+			}
+			batch.result <- p.filterBatch(batch)
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// filterBatch filters the lines of a batch up to the first error.
+func (p *ParallelStreamReader) filterBatch(batch *parallelBatch) parallelResult {
+	var out bytes.Buffer
+	for _, line := range batch.lines {
+		filtered, err := p.filter(line)
+		if err != nil {
+			return parallelResult{out: out.Bytes(), err: err}
+		}
+		out.WriteString(filtered)
+	}
+	return parallelResult{out: out.Bytes(), err: batch.err}
+}
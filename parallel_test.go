@@ -0,0 +1,162 @@
+package go_sio
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParallelStreamReader(t *testing.T) {
+	upper := func(s string) (string, error) { return strings.ToUpper(s), nil }
+	dropOdd := func(s string) (string, error) {
+		if strings.HasSuffix(strings.TrimSpace(s), "1") || strings.HasSuffix(strings.TrimSpace(s), "3") {
+			return "", nil
+		}
+		return s, nil
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		filter  StringLineFilter
+		workers int
+	}{
+		{"empty", "", upper, 4},
+		{"one line without newline", "only line", upper, 4},
+		{"many batches", numberedLines(2000), upper, 4},
+		{"drop lines", numberedLines(2000), dropOdd, 3},
+		{"single worker", numberedLines(500), upper, 1},
+		{"default workers", numberedLines(500), nil, 0},
+		{"batches by size", strings.Repeat(strings.Repeat("x", 10000)+"\n", 20), upper, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, _ := io.ReadAll(NewStreamReader(strings.NewReader(tt.data), tt.filter))
+			p := NewParallelStreamReader(strings.NewReader(tt.data), tt.filter, tt.workers)
+			defer p.Close()
+			out, err := io.ReadAll(p)
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			if string(out) != string(expected) {
+				t.Errorf("Output differs from StreamReader: got %d bytes, expected %d", len(out), len(expected))
+			}
+		})
+	}
+}
+
+func TestParallelStreamReader_FirstErrorByLineOrder(t *testing.T) {
+	filter := func(s string) (string, error) {
+		// A later line fails fast while an earlier one is still being filtered
+		switch s {
+		case "line 300\n":
+			time.Sleep(20 * time.Millisecond)
+			return "", errors.New("line 300")
+		case "line 1500\n":
+			return "", errors.New("line 1500")
+		}
+		return s, nil
+	}
+	p := NewParallelStreamReader(strings.NewReader(numberedLines(2000)), filter, 4)
+	defer p.Close()
+
+	out, err := io.ReadAll(p)
+	if err == nil || err.Error() != "line 300" {
+		t.Errorf("Expected the error of line 300, got %v", err)
+	}
+	if string(out) != numberedLines(300) {
+		t.Errorf("Expected the 300 lines before the error, got %d bytes", len(out))
+	}
+	if _, err := p.Read(make([]byte, 8)); err == nil || err.Error() != "line 300" {
+		t.Errorf("Expected the error to stick, got %v", err)
+	}
+}
+
+func TestParallelStreamReader_ReadError(t *testing.T) {
+	readErr := errors.New("read failed")
+	tests := []struct {
+		name     string
+		r        io.Reader
+		expected error
+		out      string
+	}{
+		{"reader", &failingReader{data: "a\nb\n", err: readErr}, readErr, "a\nb\n"},
+		{"line too long", strings.NewReader("a\n" + strings.Repeat("x", bufio.MaxScanTokenSize+1)), bufio.ErrTooLong, "a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParallelStreamReader(tt.r, nil, 2)
+			defer p.Close()
+			out, err := io.ReadAll(p)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+			if string(out) != tt.out {
+				t.Errorf("Expected %q, got %q", tt.out, string(out))
+			}
+		})
+	}
+}
+
+func TestParallelStreamReader_Close(t *testing.T) {
+	TrackLeaks(t)
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	p := NewParallelStreamReader(pr, nil, 2)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = p.Close()
+	}()
+	if _, err := p.Read(make([]byte, 8)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed from a blocked Read, got %v", err)
+	}
+	if _, err := p.Read(make([]byte, 8)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Expected nil from a second Close, got %v", err)
+	}
+}
+
+func TestParallelStreamReader_CloseWhileWaitingForResult(t *testing.T) {
+	started := make(chan struct{})
+	filter := func(s string) (string, error) {
+		if s == "slow\n" {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+		}
+		return s, nil
+	}
+	p := NewParallelStreamReader(strings.NewReader("slow\n"), filter, 1)
+	go func() {
+		<-started
+		_ = p.Close()
+	}()
+	if _, err := p.Read(make([]byte, 8)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestParallelStreamReader_CloseStopsScanning(t *testing.T) {
+	// Nothing reads, so scanning fills the bounded queue and waits
+	p := NewParallelStreamReader(strings.NewReader(numberedLines(10000)), nil, 1)
+	time.Sleep(20 * time.Millisecond)
+	if err := p.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	// order is closed once scanning has stopped
+	for range p.order {
+	}
+}
+
+func TestParallelStreamReader_Nil(t *testing.T) {
+	if p := NewParallelStreamReader(nil, nil, 1); p != nil {
+		t.Error("Expected nil for a nil reader")
+	}
+}
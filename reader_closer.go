@@ -1,12 +1,61 @@
 package go_sio
 
-import "io"
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrClosed = errors.New("reader is closed")
+
+// closeState gives the package's closers close-once semantics: the first
+// Close runs the underlying close function and every later call returns the
+// same result.
+type closeState struct {
+	once   sync.Once
+	closed atomic.Bool
+	err    error
+	leak   *leakRecord
+}
+
+// track registers the closer with an active leak tracker, if any.
+func (cs *closeState) track(kind string) {
+	cs.leak = trackLeak(kind)
+}
+
+func (cs *closeState) isClosed() bool {
+	return cs.closed.Load()
+}
+
+func (cs *closeState) close(f func() error) error {
+	cs.once.Do(func() {
+		cs.closed.Store(true)
+		untrackLeak(cs.leak)
+		cs.err = f()
+	})
+	return cs.err
+}
 
 type ReadCloser struct {
 	io.Reader
 	io.Closer
+	state closeState
 }
 
 func NewReadCloser(r io.Reader, c io.Closer) *ReadCloser {
-	return &ReadCloser{Reader: r, Closer: c}
+	rc := &ReadCloser{Reader: r, Closer: c}
+	rc.state.track("ReadCloser")
+	return rc
+}
+
+func (rc *ReadCloser) Read(p []byte) (n int, err error) {
+	if rc.state.isClosed() {
+		return 0, ErrClosed
+	}
+	return rc.Reader.Read(p)
+}
+
+func (rc *ReadCloser) Close() error {
+	return rc.state.close(rc.Closer.Close)
 }
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"io"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -123,7 +125,7 @@ func TestReadCloser_WithNilComponents(t *testing.T) {
 	}
 
 	buf := make([]byte, 10)
-	
+
 	// Reading from nil reader should panic
 	defer func() {
 		if r := recover(); r == nil {
@@ -189,3 +191,77 @@ func TestReadCloser_WithStandardLibraryTypes(t *testing.T) {
 		t.Error("Closer was not called")
 	}
 }
+
+// countingCloser counts Close calls and is safe for concurrent use.
+type countingCloser struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (c *countingCloser) Close() error {
+	c.calls.Add(1)
+	return c.err
+}
+
+func TestReadCloser_CloseOnce(t *testing.T) {
+	expectedErr := errors.New("close error")
+	closer := &countingCloser{err: expectedErr}
+	rc := NewReadCloser(strings.NewReader("test"), closer)
+
+	for i := 0; i < 3; i++ {
+		if err := rc.Close(); err != expectedErr {
+			t.Errorf("Close #%d: expected %v, got %v", i+1, expectedErr, err)
+		}
+	}
+	if got := closer.calls.Load(); got != 1 {
+		t.Errorf("Expected inner Close to be called once, got %d", got)
+	}
+}
+
+func TestReadCloser_ReadAfterClose(t *testing.T) {
+	rc := NewReadCloser(strings.NewReader("test"), &mockCloser{})
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	buf := make([]byte, 10)
+	n, err := rc.Read(buf)
+	if err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected 0 bytes after close, got %d", n)
+	}
+}
+
+func TestReadCloser_ConcurrentReadClose(t *testing.T) {
+	closer := &countingCloser{}
+	rc := NewReadCloser(strings.NewReader(strings.Repeat("data\n", 1000)), closer)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		buf := make([]byte, 8)
+		for {
+			if _, err := rc.Read(buf); err != nil {
+				if err != ErrClosed && err != io.EOF {
+					t.Errorf("Unexpected read error: %v", err)
+				}
+				return
+			}
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = rc.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := closer.calls.Load(); got != 1 {
+		t.Errorf("Expected inner Close to be called once, got %d", got)
+	}
+}
@@ -0,0 +1,177 @@
+package go_sio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var ErrNoRoute = errors.New("no route for line")
+
+// RouteKeyFunc returns the routing key of a line, such as its level or
+// tenant. The line includes its terminator.
+type RouteKeyFunc func(line string) string
+
+// WriterFactory creates the writer for a routing key. It may be called again
+// for a key whose writer was closed to stay under WithMaxOpenWriters, so it
+// should append rather than truncate.
+type WriterFactory func(key string) (io.WriteCloser, error)
+
+// RouterOption configures a LineRouter.
+type RouterOption func(*LineRouter)
+
+// WithRoute sends lines with key to w. Registered writers take precedence
+// over the factory and are not closed by the router.
+func WithRoute(key string, w io.Writer) RouterOption {
+	return func(r *LineRouter) { r.routes[key] = w }
+}
+
+// WithDefaultRoute sends lines that no registered writer or factory takes to
+// w. It is not closed by the router.
+func WithDefaultRoute(w io.Writer) RouterOption {
+	return func(r *LineRouter) { r.fallback = w }
+}
+
+// WithWriterFactory creates a writer for each new key on its first line.
+// A factory returning a nil writer leaves the key to the default route for
+// good; the factory is not asked about that key again.
+func WithWriterFactory(f WriterFactory) RouterOption {
+	return func(r *LineRouter) { r.factory = f }
+}
+
+// WithMaxOpenWriters caps the number of open factory-created writers. When
+// the factory creates one at the cap, the least recently used one is closed.
+// 0 or less means no cap.
+func WithMaxOpenWriters(n int) RouterOption {
+	return func(r *LineRouter) { r.maxOpen = n }
+}
+
+// LineRouter dispatches each line to a writer chosen by the line's key. Use
+// Filter as the StringLineFilter of a StreamReader or LineWriter to route
+// their lines. It is safe for concurrent use.
+type LineRouter struct {
+	key       RouteKeyFunc
+	routes    map[string]io.Writer
+	fallback  io.Writer
+	factory   WriterFactory
+	maxOpen   int
+	mu        sync.Mutex
+	open      map[string]*routeWriter
+	defaulted map[string]bool // keys the factory left to the default route
+	uses      uint64
+	closed    bool // set under mu, so no writer is created after Close
+	state     closeState
+}
+
+type routeWriter struct {
+	w       io.WriteCloser
+	lastUse uint64
+}
+
+// NewLineRouter returns a LineRouter keying lines with key. Returns nil when
+// key is nil.
+func NewLineRouter(key RouteKeyFunc, opts ...RouterOption) *LineRouter {
+	if key == nil {
+		return nil
+	}
+	r := &LineRouter{
+		key:       key,
+		routes:    make(map[string]io.Writer),
+		open:      make(map[string]*routeWriter),
+		defaulted: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.state.track("LineRouter")
+	return r
+}
+
+// Route writes line to the writer for its key: a registered writer, else
+// one from the factory, else the default route. A line with no writer fails
+// with an error wrapping ErrNoRoute.
+func (r *LineRouter) Route(line string) error {
+	key := r.key(line)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrClosed
+	}
+	w, err := r.writer(key)
+	if w == nil {
+		return err
+	}
+	if _, writeErr := io.WriteString(w, line); writeErr != nil {
+		return errors.Join(err, writeErr)
+	}
+	return err
+}
+
+// Filter routes line and drops it from the stream being filtered.
+func (r *LineRouter) Filter(line string) (string, error) {
+	return "", r.Route(line)
+}
+
+// Close closes every writer the factory created and is still open. Later
+// Routes return ErrClosed.
+func (r *LineRouter) Close() error {
+	return r.state.close(func() error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.closed = true
+		var errs []error
+		for key, rw := range r.open {
+			errs = append(errs, rw.w.Close())
+			delete(r.open, key)
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// writer finds or creates the writer for key. A new factory writer at the
+// cap evicts another one; if closing that fails, the new writer is still
+// returned with the error. It is called with r.mu held.
+func (r *LineRouter) writer(key string) (io.Writer, error) {
+	if w, ok := r.routes[key]; ok {
+		return w, nil
+	}
+	r.uses++
+	if rw, ok := r.open[key]; ok {
+		rw.lastUse = r.uses
+		return rw.w, nil
+	}
+	if r.factory != nil && !r.defaulted[key] {
+		w, err := r.factory(key)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", key, err)
+		}
+		if w != nil {
+			var evictErr error
+			if r.maxOpen > 0 && len(r.open) >= r.maxOpen {
+				evictErr = r.evict()
+			}
+			r.open[key] = &routeWriter{w: w, lastUse: r.uses}
+			return w, evictErr
+		}
+		r.defaulted[key] = true
+	}
+	if r.fallback != nil {
+		return r.fallback, nil
+	}
+	return nil, fmt.Errorf("%w: key %q", ErrNoRoute, key)
+}
+
+// evict closes the least recently used factory-created writer.
+func (r *LineRouter) evict() error {
+	var oldest string
+	found := false
+	for key, rw := range r.open {
+		if !found || rw.lastUse < r.open[oldest].lastUse {
+			oldest, found = key, true
+		}
+	}
+	rw := r.open[oldest]
+	delete(r.open, oldest)
+	return rw.w.Close()
+}
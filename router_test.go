@@ -0,0 +1,251 @@
+package go_sio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// routeSink records what a WriterFactory created, written and closed.
+type routeSink struct {
+	data    map[string]*bytes.Buffer
+	created []string
+	closed  []string
+	err     error // returned by Close
+}
+
+func newRouteSink() *routeSink {
+	return &routeSink{data: make(map[string]*bytes.Buffer)}
+}
+
+func (s *routeSink) factory(key string) (io.WriteCloser, error) {
+	s.created = append(s.created, key)
+	if s.data[key] == nil {
+		s.data[key] = &bytes.Buffer{}
+	}
+	return writeCloser{s.data[key], closerFunc(func() error {
+		s.closed = append(s.closed, key)
+		return s.err
+	})}, nil
+}
+
+// writeCloser joins an io.Writer and an io.Closer.
+type writeCloser struct {
+	io.Writer
+	io.Closer
+}
+
+func levelKey(line string) string {
+	level, _, _ := strings.Cut(line, " ")
+	return level
+}
+
+func TestLineRouter(t *testing.T) {
+	var errLines, other bytes.Buffer
+	sink := newRouteSink()
+	router := NewLineRouter(levelKey,
+		WithRoute("ERROR", &errLines),
+		WithWriterFactory(sink.factory),
+		WithDefaultRoute(&other))
+
+	data := "INFO started\nERROR failed\nDEBUG detail\nINFO done\nERROR again"
+	out, err := io.ReadAll(NewStreamReader(strings.NewReader(data), router.Filter))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(out) != 0 {
+		t.Errorf("Expected routed lines dropped from the stream, got %q", string(out))
+	}
+	if err := router.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if errLines.String() != "ERROR failed\nERROR again" {
+		t.Errorf("Unexpected ERROR route %q", errLines.String())
+	}
+	if sink.data["INFO"].String() != "INFO started\nINFO done\n" || sink.data["DEBUG"].String() != "DEBUG detail\n" {
+		t.Errorf("Unexpected factory writers %q, %q", sink.data["INFO"].String(), sink.data["DEBUG"].String())
+	}
+	if strings.Join(sink.created, ",") != "INFO,DEBUG" || len(sink.closed) != 2 {
+		t.Errorf("Expected INFO and DEBUG created once and closed, got %v, %v", sink.created, sink.closed)
+	}
+	if other.Len() != 0 {
+		t.Errorf("Expected nothing on the default route, got %q", other.String())
+	}
+}
+
+func TestLineRouter_LineWriter(t *testing.T) {
+	var info, other bytes.Buffer
+	router := NewLineRouter(levelKey, WithRoute("INFO", &info), WithDefaultRoute(&other))
+	defer router.Close()
+	lw := NewLineWriter(io.Discard, router.Filter)
+
+	_, _ = io.WriteString(lw, "INFO a\nWARN b\nIN")
+	_, _ = io.WriteString(lw, "FO c\n")
+	if err := lw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if info.String() != "INFO a\nINFO c\n" || other.String() != "WARN b\n" {
+		t.Errorf("Unexpected routes %q, %q", info.String(), other.String())
+	}
+}
+
+func TestLineRouter_MaxOpenWriters(t *testing.T) {
+	sink := newRouteSink()
+	router := NewLineRouter(levelKey, WithWriterFactory(sink.factory), WithMaxOpenWriters(2))
+	for _, line := range []string{"a 1\n", "b 1\n", "a 2\n", "c 1\n", "b 2\n", " no level\n"} {
+		if err := router.Route(line); err != nil {
+			t.Fatalf("Route(%q) failed: %v", line, err)
+		}
+	}
+	// Each new key evicts the least recently used: b for c, a for b again,
+	// then c for ""
+	if got := strings.Join(sink.closed, ","); got != "b,a,c" {
+		t.Errorf("Expected b, a and c evicted, got %q", got)
+	}
+	if err := router.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if len(sink.closed) != 5 {
+		t.Errorf("Expected every created writer closed, got %v", sink.closed)
+	}
+	if sink.data["a"].String() != "a 1\na 2\n" || sink.data["b"].String() != "b 1\nb 2\n" || sink.data[""].String() != " no level\n" {
+		t.Errorf("Expected reopened writers to append, got %q, %q", sink.data["a"].String(), sink.data["b"].String())
+	}
+}
+
+func TestLineRouter_FactoryDeclinesAtCap(t *testing.T) {
+	var other bytes.Buffer
+	sink := newRouteSink()
+	calls := map[string]int{}
+	factory := func(key string) (io.WriteCloser, error) {
+		calls[key]++
+		switch key {
+		case "debug":
+			return nil, nil
+		case "bad":
+			return nil, errors.New("open failed")
+		}
+		return sink.factory(key)
+	}
+	router := NewLineRouter(levelKey, WithWriterFactory(factory), WithMaxOpenWriters(1), WithDefaultRoute(&other))
+
+	for range 4 {
+		_ = router.Route("info x\n")
+		_ = router.Route("debug y\n")
+		_ = router.Route("bad z\n")
+	}
+	// Neither a declining nor a failing factory evicts the open writer
+	if len(sink.created) != 1 || len(sink.closed) != 0 {
+		t.Errorf("Expected info created once and kept open, got %v created, %v closed", sink.created, sink.closed)
+	}
+	if calls["debug"] != 1 || calls["bad"] != 4 {
+		t.Errorf("Expected the declined key asked once and the failing one every time, got %v", calls)
+	}
+	if other.String() != strings.Repeat("debug y\n", 4) {
+		t.Errorf("Unexpected default route %q", other.String())
+	}
+	if err := router.Close(); err != nil || len(sink.closed) != 1 {
+		t.Errorf("Expected info closed by Close, got %v, %v", err, sink.closed)
+	}
+}
+
+func TestLineRouter_CloseRacingRoute(t *testing.T) {
+	for range 20 {
+		sink := newRouteSink()
+		router := NewLineRouter(levelKey, WithWriterFactory(sink.factory))
+
+		// Hold the lock so the Route is past its entry and waiting when
+		// Close starts
+		router.mu.Lock()
+		var wg sync.WaitGroup
+		var routeErr error
+		wg.Go(func() { routeErr = router.Route("info x\n") })
+		time.Sleep(time.Millisecond)
+		wg.Go(func() { _ = router.Close() })
+		time.Sleep(time.Millisecond)
+		router.mu.Unlock()
+		wg.Wait()
+
+		if len(sink.created) != len(sink.closed) {
+			t.Fatalf("Expected every created writer closed, got %v created, %v closed", sink.created, sink.closed)
+		}
+		if routeErr != nil && (!errors.Is(routeErr, ErrClosed) || len(sink.created) != 0) {
+			t.Fatalf("Expected ErrClosed and no writer, got %v, %v", routeErr, sink.created)
+		}
+	}
+}
+
+func TestLineRouter_Errors(t *testing.T) {
+	factoryErr := errors.New("open failed")
+	closeErr := errors.New("close failed")
+	writeErr := errors.New("write failed")
+
+	t.Run("no route", func(t *testing.T) {
+		router := NewLineRouter(levelKey)
+		defer router.Close()
+		if err := router.Route("INFO x\n"); !errors.Is(err, ErrNoRoute) {
+			t.Errorf("Expected ErrNoRoute, got %v", err)
+		}
+	})
+	t.Run("factory error", func(t *testing.T) {
+		router := NewLineRouter(levelKey, WithWriterFactory(func(string) (io.WriteCloser, error) { return nil, factoryErr }))
+		defer router.Close()
+		if _, err := router.Filter("INFO x\n"); !errors.Is(err, factoryErr) {
+			t.Errorf("Expected %v, got %v", factoryErr, err)
+		}
+	})
+	t.Run("factory declines", func(t *testing.T) {
+		var other bytes.Buffer
+		router := NewLineRouter(levelKey, WithWriterFactory(func(string) (io.WriteCloser, error) { return nil, nil }), WithDefaultRoute(&other))
+		defer router.Close()
+		if err := router.Route("INFO x\n"); err != nil || other.String() != "INFO x\n" {
+			t.Errorf("Expected the default route, got %q, %v", other.String(), err)
+		}
+	})
+	t.Run("write error", func(t *testing.T) {
+		router := NewLineRouter(levelKey, WithDefaultRoute(&failingWriter{err: writeErr}))
+		defer router.Close()
+		if err := router.Route("INFO x\n"); !errors.Is(err, writeErr) {
+			t.Errorf("Expected %v, got %v", writeErr, err)
+		}
+	})
+	t.Run("eviction close error", func(t *testing.T) {
+		sink := newRouteSink()
+		sink.err = closeErr
+		router := NewLineRouter(levelKey, WithWriterFactory(sink.factory), WithMaxOpenWriters(1))
+		_ = router.Route("a x\n")
+		if err := router.Route("b x\n"); !errors.Is(err, closeErr) {
+			t.Errorf("Expected %v, got %v", closeErr, err)
+		}
+		if sink.data["b"].String() != "b x\n" {
+			t.Errorf("Expected the line routed despite the eviction error, got %q", sink.data["b"].String())
+		}
+		if err := router.Close(); !errors.Is(err, closeErr) || strings.Join(sink.closed, ",") != "a,b" {
+			t.Errorf("Expected b closed by Close, got %v, %v", err, sink.closed)
+		}
+	})
+	t.Run("close error", func(t *testing.T) {
+		sink := newRouteSink()
+		sink.err = closeErr
+		router := NewLineRouter(levelKey, WithWriterFactory(sink.factory))
+		_ = router.Route("a x\n")
+		_ = router.Route("b x\n")
+		if err := router.Close(); !errors.Is(err, closeErr) || len(sink.closed) != 2 {
+			t.Errorf("Expected %v from closing both writers, got %v, %v", closeErr, err, sink.closed)
+		}
+		if err := router.Route("a y\n"); !errors.Is(err, ErrClosed) {
+			t.Errorf("Expected ErrClosed, got %v", err)
+		}
+	})
+}
+
+func TestLineRouter_Nil(t *testing.T) {
+	if r := NewLineRouter(nil); r != nil {
+		t.Error("Expected nil for a nil key func")
+	}
+}
@@ -6,23 +6,44 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"strings"
 )
 
 var (
-	ErrNilReader = errors.New("reader is nil")
-	NopFilter StringLineFilter = func(in string) (string, error) { return in, nil }
+	ErrNilReader                  = errors.New("reader is nil")
+	NopFilter    StringLineFilter = func(in string) (string, error) { return in, nil }
 )
 
 type StringLineFilter func(string) (string, error)
 
+// StreamOption configures a StreamReader.
+type StreamOption func(*StreamReader)
+
+// WithSplitFunc replaces the '\n' line split with another delimiter mode,
+// such as ScanOctetCounted. Tokens are passed to the filter as lines.
+func WithSplitFunc(f bufio.SplitFunc) StreamOption {
+	return func(sr *StreamReader) { sr.splitFunc = f }
+}
+
 type StreamReader struct {
 	scanner    *bufio.Scanner
+	splitFunc  bufio.SplitFunc
 	filter     StringLineFilter
 	buffer     bytes.Buffer
 	existsData bool
+
+	utf8Policy  UTF8Policy
+	stripBOM    bool
+	bomDetected bool
+	lines       int
+
+	scanned  int64 // input bytes taken by the split function
+	lineEnd  int64 // input offset after the last line passed or dropped
+	consumed int64 // lineEnd once that line's output has been read
+	failed   bool  // a line failed, so consumed stays before it
 }
 
-func NewStreamReader(r io.Reader, f StringLineFilter) *StreamReader {
+func NewStreamReader(r io.Reader, f StringLineFilter, opts ...StreamOption) *StreamReader {
 	if r == nil {
 		return nil
 	}
@@ -31,14 +52,34 @@ func NewStreamReader(r io.Reader, f StringLineFilter) *StreamReader {
 	}
 	sr := &StreamReader{
 		scanner:    bufio.NewScanner(r),
+		splitFunc:  split,
 		existsData: true,
 		filter:     f,
 	}
+	for _, opt := range opts {
+		opt(sr)
+	}
 
-	sr.scanner.Split(split)
+	sr.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := sr.splitFunc(data, atEOF)
+		sr.scanned += int64(advance)
+		return advance, token, err
+	})
 	return sr
 }
 
+// Offset returns the input byte offset just past the last line that has been
+// fully consumed: its filtered output has been read completely, or it was
+// dropped. Once a line fails with an error, Offset stays before it even if
+// later Reads go on past it, so reading again from this offset repeats the
+// failed line but no output that was read before it.
+func (sr *StreamReader) Offset() int64 {
+	if sr == nil {
+		return 0
+	}
+	return sr.consumed
+}
+
 func (sr *StreamReader) Read(p []byte) (n int, err error) {
 	if sr == nil {
 		return 0, ErrNilReader
@@ -53,10 +94,29 @@ func (sr *StreamReader) Read(p []byte) (n int, err error) {
 		}
 
 		lineBytes = sr.scanner.Bytes()
-		lineStr, bufErr = sr.filter(string(lineBytes))
+		sr.lines++
+		lineStr = string(lineBytes)
+		if sr.lines == 1 && strings.HasPrefix(lineStr, utf8BOM) {
+			sr.bomDetected = true
+			if sr.stripBOM {
+				lineStr = lineStr[len(utf8BOM):]
+			}
+		}
+		var keep bool
+		if lineStr, keep, bufErr = sr.checkUTF8(lineStr); bufErr != nil {
+			sr.failed = true
+			break
+		}
+		if !keep {
+			sr.lineEnd = sr.scanned
+			continue
+		}
+		lineStr, bufErr = sr.filter(lineStr)
 		if bufErr != nil {
+			sr.failed = true
 			break
 		}
+		sr.lineEnd = sr.scanned
 		if lineStr != "" {
 			_, _ = sr.buffer.Write([]byte(lineStr))
 			break
@@ -68,7 +128,11 @@ func (sr *StreamReader) Read(p []byte) (n int, err error) {
 	}
 
 	if bufErr == nil {
-		return sr.buffer.Read(p)
+		n, err = sr.buffer.Read(p)
+		if sr.buffer.Len() == 0 && !sr.failed {
+			sr.consumed = sr.lineEnd
+		}
+		return n, err
 	}
 	return 0, bufErr
 }
@@ -87,17 +151,25 @@ func split(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	return 0, nil, nil
 }
 
+// cutLineEnd splits a line into its content and its "\n" or "\r\n" terminator.
+func cutLineEnd(line string) (content, end string) {
+	if strings.HasSuffix(line, "\r\n") {
+		return line[:len(line)-2], "\r\n"
+	}
+	if strings.HasSuffix(line, "\n") {
+		return line[:len(line)-1], "\n"
+	}
+	return line, ""
+}
+
+// ValidJSONFilter keeps lines that are valid JSON and drops the rest.
+var ValidJSONFilter StringLineFilter = func(in string) (string, error) {
+	if json.Valid([]byte(in)) {
+		return in, nil
+	}
+	return "", nil
+}
+
 func NewJSONFilterReadCloser(r io.ReadCloser) io.ReadCloser {
-	return NewReadCloser(
-		NewStreamReader(
-			r,
-			func(in string) (string, error) {
-				if json.Valid([]byte(in)) {
-					return in, nil
-				}
-				return "", nil
-			},
-		),
-		r,
-	)
+	return NewReadCloser(NewStreamReader(r, ValidJSONFilter), r)
 }
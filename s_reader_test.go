@@ -1,6 +1,7 @@
 package go_sio
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"io"
@@ -112,7 +113,7 @@ func TestStreamReader_Read_BasicFunctionality(t *testing.T) {
 func TestStreamReader_Read_WithFilter(t *testing.T) {
 	data := "keep\nskip\nkeep\n"
 	reader := strings.NewReader(data)
-	
+
 	// Filter that skips lines containing "skip"
 	filter := func(line string) (string, error) {
 		if strings.Contains(line, "skip") {
@@ -120,7 +121,7 @@ func TestStreamReader_Read_WithFilter(t *testing.T) {
 		}
 		return strings.ToUpper(line), nil
 	}
-	
+
 	sr := NewStreamReader(reader, filter)
 
 	// First read should get "KEEP\n"
@@ -155,14 +156,14 @@ func TestStreamReader_Read_FilterError(t *testing.T) {
 	data := "line1\nline2\n"
 	reader := strings.NewReader(data)
 	expectedErr := errors.New("filter error")
-	
+
 	filter := func(line string) (string, error) {
 		if strings.Contains(line, "line2") {
 			return "", expectedErr
 		}
 		return line, nil
 	}
-	
+
 	sr := NewStreamReader(reader, filter)
 
 	// First read should succeed
@@ -257,12 +258,12 @@ func TestStreamReader_Read_NoFinalNewline(t *testing.T) {
 
 func TestSplit(t *testing.T) {
 	tests := []struct {
-		name      string
-		data      []byte
-		atEOF     bool
-		advance   int
-		token     []byte
-		err       error
+		name    string
+		data    []byte
+		atEOF   bool
+		advance int
+		token   []byte
+		err     error
 	}{
 		{
 			name:    "empty data at EOF",
@@ -342,7 +343,7 @@ not json at all
 ["array", "is", "valid"]
 `
 	reader := io.NopCloser(strings.NewReader(data))
-	
+
 	rc := NewJSONFilterReadCloser(reader)
 	if rc == nil {
 		t.Fatal("NewJSONFilterReadCloser returned nil")
@@ -373,12 +374,12 @@ not json at all
 func TestNewJSONFilterReadCloser_EmptyInput(t *testing.T) {
 	reader := io.NopCloser(strings.NewReader(""))
 	rc := NewJSONFilterReadCloser(reader)
-	
+
 	result, err := io.ReadAll(rc)
 	if err != nil {
 		t.Fatalf("ReadAll failed: %v", err)
 	}
-	
+
 	if len(result) != 0 {
 		t.Errorf("Expected empty result, got %q", string(result))
 	}
@@ -391,13 +392,80 @@ also not json
 `
 	reader := io.NopCloser(strings.NewReader(data))
 	rc := NewJSONFilterReadCloser(reader)
-	
+
 	result, err := io.ReadAll(rc)
 	if err != nil {
 		t.Fatalf("ReadAll failed: %v", err)
 	}
-	
+
 	if len(result) != 0 {
 		t.Errorf("Expected empty result, got %q", string(result))
 	}
 }
+
+func TestStreamReader_WithSplitFunc(t *testing.T) {
+	sr := NewStreamReader(strings.NewReader("one two  three"), func(s string) (string, error) {
+		return "[" + s + "]", nil
+	}, WithSplitFunc(bufio.ScanWords))
+
+	out, err := io.ReadAll(sr)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "[one][two][three]" {
+		t.Errorf("Expected %q, got %q", "[one][two][three]", string(out))
+	}
+}
+
+func TestStreamReader_Offset(t *testing.T) {
+	var nilReader *StreamReader
+	if nilReader.Offset() != 0 {
+		t.Error("Expected 0 for nil StreamReader")
+	}
+
+	filterErr := errors.New("filter error")
+	filter := func(in string) (string, error) {
+		switch {
+		case strings.HasPrefix(in, "drop"):
+			return "", nil
+		case strings.HasPrefix(in, "fail"):
+			return "", filterErr
+		}
+		return strings.ToUpper(in), nil
+	}
+	sr := NewStreamReader(strings.NewReader("keep\ndrop\nfail\nlong line\ndrop\n"), filter)
+
+	steps := []struct {
+		bufSize int
+		out     string
+		err     error
+		offset  int64
+	}{
+		{10, "KEEP\n", nil, 5},
+		{10, "", filterErr, 5},
+		{4, "LONG", nil, 5},
+		{10, " LINE\n", nil, 5}, // stays before the failed line
+		{10, "", io.EOF, 5},
+	}
+	for i, step := range steps {
+		buf := make([]byte, step.bufSize)
+		n, err := sr.Read(buf)
+		if string(buf[:n]) != step.out || err != step.err {
+			t.Errorf("Step %d: expected %q, %v, got %q, %v", i, step.out, step.err, string(buf[:n]), err)
+		}
+		if sr.Offset() != step.offset {
+			t.Errorf("Step %d: expected offset %d, got %d", i, step.offset, sr.Offset())
+		}
+	}
+}
+
+func TestStreamReader_OffsetOctetCounted(t *testing.T) {
+	sr := NewStreamReader(strings.NewReader("5 hello3 abc"), NopFilter, WithSplitFunc(ScanOctetCounted))
+	out, err := io.ReadAll(sr)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "hello\nabc\n" || sr.Offset() != 12 {
+		t.Errorf("Expected all frames consumed at offset 12, got %q at %d", string(out), sr.Offset())
+	}
+}
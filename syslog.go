@@ -0,0 +1,316 @@
+package go_sio
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSyslog = errors.New("invalid syslog message")
+	ErrInvalidFrame  = errors.New("invalid octet-counted frame")
+
+	// SyslogToJSONFilter converts RFC 5424 and RFC 3164 lines to compact JSON
+	// objects. Lines that cannot be parsed are dropped.
+	SyslogToJSONFilter StringLineFilter = syslogToJSON
+)
+
+// SyslogSeverity is the severity part of a syslog priority. Lower values are
+// more severe.
+type SyslogSeverity int
+
+const (
+	SeverityEmergency SyslogSeverity = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// SyslogMessage is a parsed syslog record. Version is 1 for RFC 5424 and 0 for
+// RFC 3164 messages. Fields that are absent or set to the RFC 5424 NILVALUE
+// "-" are left empty.
+type SyslogMessage struct {
+	Priority       int
+	Facility       int
+	Severity       SyslogSeverity
+	Version        int
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string
+	Message        string
+}
+
+// ParseSyslog parses an RFC 5424 message, or an RFC 3164 message when the
+// priority is not followed by a version number.
+func ParseSyslog(line string) (*SyslogMessage, error) {
+	line, _ = cutLineEnd(line)
+	_, rest, err := parsePriority(line)
+	if err != nil {
+		return nil, err
+	}
+	if version, _, ok := strings.Cut(rest, " "); ok {
+		if v, err := strconv.Atoi(version); err == nil && v > 0 {
+			return ParseRFC5424(line)
+		}
+	}
+	return ParseRFC3164(line)
+}
+
+// ParseRFC5424 parses "<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID
+// STRUCTURED-DATA [MSG]". A UTF-8 BOM at the start of MSG is removed.
+func ParseRFC5424(line string) (*SyslogMessage, error) {
+	line, _ = cutLineEnd(line)
+	pri, rest, err := parsePriority(line)
+	if err != nil {
+		return nil, err
+	}
+	m := &SyslogMessage{Priority: pri, Facility: pri / 8, Severity: SyslogSeverity(pri % 8)}
+
+	var fields [6]string
+	for i := range fields {
+		var ok bool
+		fields[i], rest, ok = strings.Cut(rest, " ")
+		if !ok || fields[i] == "" {
+			return nil, fmt.Errorf("%w: missing header field", ErrInvalidSyslog)
+		}
+	}
+	if m.Version, err = strconv.Atoi(fields[0]); err != nil || m.Version < 1 {
+		return nil, fmt.Errorf("%w: bad version %q", ErrInvalidSyslog, fields[0])
+	}
+	if fields[1] != "-" {
+		if m.Timestamp, err = time.Parse(time.RFC3339Nano, fields[1]); err != nil {
+			return nil, fmt.Errorf("%w: bad timestamp %q", ErrInvalidSyslog, fields[1])
+		}
+	}
+	m.Hostname = nilValue(fields[2])
+	m.AppName = nilValue(fields[3])
+	m.ProcID = nilValue(fields[4])
+	m.MsgID = nilValue(fields[5])
+
+	if m.StructuredData, rest, err = parseStructuredData(rest); err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		if rest[0] != ' ' {
+			return nil, fmt.Errorf("%w: missing space before message", ErrInvalidSyslog)
+		}
+		m.Message = strings.TrimPrefix(rest[1:], "\uFEFF")
+	}
+	return m, nil
+}
+
+// ParseRFC3164 parses the legacy BSD format "<PRI>Mmm dd hh:mm:ss HOSTNAME
+// TAG[PID]: MSG". The timestamp has no year, so the current year is assumed,
+// or the previous one when that would put the message more than a day in the
+// future. A tag is only recognized when it is followed by ':' or "[PID]:".
+func ParseRFC3164(line string) (*SyslogMessage, error) {
+	line, _ = cutLineEnd(line)
+	pri, rest, err := parsePriority(line)
+	if err != nil {
+		return nil, err
+	}
+	m := &SyslogMessage{Priority: pri, Facility: pri / 8, Severity: SyslogSeverity(pri % 8)}
+
+	if len(rest) < len(time.Stamp)+1 || rest[len(time.Stamp)] != ' ' {
+		return nil, fmt.Errorf("%w: missing timestamp", ErrInvalidSyslog)
+	}
+	ts, err := time.ParseInLocation(time.Stamp, rest[:len(time.Stamp)], time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad timestamp %q", ErrInvalidSyslog, rest[:len(time.Stamp)])
+	}
+	m.Timestamp = withYear(ts, time.Now())
+
+	var ok bool
+	m.Hostname, rest, ok = strings.Cut(rest[len(time.Stamp)+1:], " ")
+	if !ok || m.Hostname == "" {
+		return nil, fmt.Errorf("%w: missing hostname", ErrInvalidSyslog)
+	}
+
+	m.Message = rest
+	if tag, msg, ok := strings.Cut(rest, ":"); ok && tag != "" && !strings.ContainsAny(tag, " \t") {
+		if name, pid, ok := strings.Cut(tag, "["); ok && strings.HasSuffix(pid, "]") {
+			m.AppName, m.ProcID = name, strings.TrimSuffix(pid, "]")
+		} else {
+			m.AppName = tag
+		}
+		m.Message = strings.TrimPrefix(msg, " ")
+	}
+	return m, nil
+}
+
+// withYear places a year-less timestamp in the year of now, or in the year
+// before when that would be more than a day ahead of now.
+func withYear(ts, now time.Time) time.Time {
+	t := ts.AddDate(now.Year()-ts.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+func parsePriority(line string) (int, string, error) {
+	end := strings.IndexByte(line, '>')
+	if len(line) < 3 || line[0] != '<' || end < 2 || end > 4 {
+		return 0, "", fmt.Errorf("%w: missing priority", ErrInvalidSyslog)
+	}
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri < 0 || pri > 191 || (end > 2 && line[1] == '0') {
+		return 0, "", fmt.Errorf("%w: bad priority %q", ErrInvalidSyslog, line[1:end])
+	}
+	return pri, line[end+1:], nil
+}
+
+func nilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+// parseStructuredData parses the STRUCTURED-DATA part at the start of s and
+// returns the remainder.
+func parseStructuredData(s string) (map[string]map[string]string, string, error) {
+	if strings.HasPrefix(s, "-") {
+		return nil, s[1:], nil
+	}
+	if !strings.HasPrefix(s, "[") {
+		return nil, "", fmt.Errorf("%w: missing structured data", ErrInvalidSyslog)
+	}
+	sd := make(map[string]map[string]string)
+	for strings.HasPrefix(s, "[") {
+		id, rest, _ := strings.Cut(s[1:], " ")
+		if i := strings.IndexByte(id, ']'); i >= 0 {
+			id, rest = id[:i], s[1+i:]
+		} else {
+			rest = " " + rest
+		}
+		if id == "" {
+			return nil, "", fmt.Errorf("%w: empty SD-ID", ErrInvalidSyslog)
+		}
+		params := make(map[string]string)
+		for strings.HasPrefix(rest, " ") {
+			name, value, ok := strings.Cut(rest[1:], "=\"")
+			if !ok || name == "" || strings.ContainsAny(name, " ]\"") {
+				return nil, "", fmt.Errorf("%w: bad SD-PARAM in %q", ErrInvalidSyslog, id)
+			}
+			var sb strings.Builder
+			i := 0
+			for ; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' && i+1 < len(value) && strings.IndexByte(`"\]`, value[i+1]) >= 0 {
+					i++
+				}
+				sb.WriteByte(value[i])
+			}
+			if i == len(value) {
+				return nil, "", fmt.Errorf("%w: unterminated SD-PARAM in %q", ErrInvalidSyslog, id)
+			}
+			params[name] = sb.String()
+			rest = value[i+1:]
+		}
+		if !strings.HasPrefix(rest, "]") {
+			return nil, "", fmt.Errorf("%w: unterminated SD-ELEMENT %q", ErrInvalidSyslog, id)
+		}
+		sd[id] = params
+		s = rest[1:]
+	}
+	return sd, s, nil
+}
+
+// NewSyslogSeverityFilter keeps syslog lines whose severity is maxSeverity or
+// more severe, and drops all other lines, including those that cannot be
+// parsed.
+func NewSyslogSeverityFilter(maxSeverity SyslogSeverity) StringLineFilter {
+	return func(in string) (string, error) {
+		m, err := ParseSyslog(in)
+		if err != nil || m.Severity > maxSeverity {
+			return "", nil
+		}
+		return in, nil
+	}
+}
+
+func syslogToJSON(in string) (string, error) {
+	m, err := ParseSyslog(in)
+	if err != nil {
+		return "", nil
+	}
+	fields := []jsonField{
+		{"priority", m.Priority},
+		{"facility", m.Facility},
+		{"severity", int(m.Severity)},
+		{"version", m.Version},
+	}
+	if !m.Timestamp.IsZero() {
+		fields = append(fields, jsonField{"timestamp", m.Timestamp.Format(time.RFC3339Nano)})
+	}
+	for _, f := range []jsonField{
+		{"hostname", m.Hostname},
+		{"app_name", m.AppName},
+		{"procid", m.ProcID},
+		{"msgid", m.MsgID},
+	} {
+		if f.value != "" {
+			fields = append(fields, f)
+		}
+	}
+	if len(m.StructuredData) > 0 {
+		fields = append(fields, jsonField{"structured_data", m.StructuredData})
+	}
+	fields = append(fields, jsonField{"message", m.Message})
+
+	_, end := cutLineEnd(in)
+	out, _ := marshalJSONObject(fields) // strings, ints and string maps always encode
+	return out + end, nil
+}
+
+// ScanOctetCounted is a bufio.SplitFunc for the RFC 6587 octet-counting
+// framing "MSG-LEN SP SYSLOG-MSG". Whitespace between frames is skipped and
+// each message is returned with a single trailing '\n', so line filters see
+// the same shape as in newline framing; messages may still contain embedded
+// newlines.
+func ScanOctetCounted(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := 0
+	for start < len(data) && (data[start] == '\n' || data[start] == '\r' || data[start] == ' ') {
+		start++
+	}
+	if start == len(data) {
+		return start, nil, nil
+	}
+
+	sp := start
+	for sp < len(data) && data[sp] >= '0' && data[sp] <= '9' {
+		sp++
+	}
+	if sp == len(data) {
+		if atEOF {
+			return 0, nil, fmt.Errorf("%w: truncated length", ErrInvalidFrame)
+		}
+		return start, nil, nil
+	}
+	if sp == start || data[sp] != ' ' || sp-start > 9 {
+		return 0, nil, fmt.Errorf("%w: bad length prefix", ErrInvalidFrame)
+	}
+	length, _ := strconv.Atoi(string(data[start:sp]))
+	end := sp + 1 + length
+	if end > len(data) {
+		if atEOF {
+			return 0, nil, fmt.Errorf("%w: truncated message", ErrInvalidFrame)
+		}
+		return start, nil, nil
+	}
+
+	msg := data[sp+1 : end]
+	if len(msg) == 0 || msg[len(msg)-1] != '\n' {
+		msg = append(msg[:len(msg):len(msg)], '\n')
+	}
+	return end, msg, nil
+}
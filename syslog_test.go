@@ -0,0 +1,368 @@
+package go_sio
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRFC5424(t *testing.T) {
+	line := `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 ` +
+		`[exampleSDID@32473 iut="3" eventSource="Application"][examplePriority@32473 class="hi\"gh\]"] ` +
+		"\uFEFFAn application event log entry...\n"
+
+	m, err := ParseRFC5424(line)
+	if err != nil {
+		t.Fatalf("ParseRFC5424 failed: %v", err)
+	}
+	expected := &SyslogMessage{
+		Priority:  165,
+		Facility:  20,
+		Severity:  SeverityNotice,
+		Version:   1,
+		Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC),
+		Hostname:  "mymachine.example.com",
+		AppName:   "evntslog",
+		MsgID:     "ID47",
+		StructuredData: map[string]map[string]string{
+			"exampleSDID@32473":     {"iut": "3", "eventSource": "Application"},
+			"examplePriority@32473": {"class": `hi"gh]`},
+		},
+		Message: "An application event log entry...",
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, m)
+	}
+}
+
+func TestParseRFC5424_Minimal(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected *SyslogMessage
+	}{
+		{
+			name:     "all nil values",
+			line:     "<0>1 - - - - - -",
+			expected: &SyslogMessage{Version: 1},
+		},
+		{
+			name: "empty SD element and message",
+			line: "<34>1 2003-10-11T22:14:15+02:00 host su - ID47 [meta] 'su root' failed",
+			expected: &SyslogMessage{
+				Priority: 34, Facility: 4, Severity: SeverityCritical, Version: 1,
+				Timestamp:      time.Date(2003, 10, 11, 22, 14, 15, 0, time.FixedZone("", 2*3600)),
+				Hostname:       "host",
+				AppName:        "su",
+				MsgID:          "ID47",
+				StructuredData: map[string]map[string]string{"meta": {}},
+				Message:        "'su root' failed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseRFC5424(tt.line)
+			if err != nil {
+				t.Fatalf("ParseRFC5424 failed: %v", err)
+			}
+			if !m.Timestamp.Equal(tt.expected.Timestamp) {
+				t.Errorf("Expected timestamp %v, got %v", tt.expected.Timestamp, m.Timestamp)
+			}
+			m.Timestamp, tt.expected.Timestamp = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(m, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, m)
+			}
+		})
+	}
+}
+
+func TestParseRFC5424_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		msg  string
+	}{
+		{"no priority", "1 - - - - - -", "missing priority"},
+		{"empty priority", "<>1 - - - - - -", "missing priority"},
+		{"long priority", "<1234>1 - - - - - -", "missing priority"},
+		{"short line", "<1", "missing priority"},
+		{"priority not a number", "<ab>1 - - - - - -", `bad priority "ab"`},
+		{"priority out of range", "<192>1 - - - - - -", `bad priority "192"`},
+		{"priority leading zero", "<01>1 - - - - - -", `bad priority "01"`},
+		{"missing fields", "<1>1 - - -", "missing header field"},
+		{"double space", "<1>1  - - - - -", "missing header field"},
+		{"bad version", "<1>x - - - - - -", `bad version "x"`},
+		{"zero version", "<1>0 - - - - - -", `bad version "0"`},
+		{"bad timestamp", "<1>1 yesterday - - - - -", `bad timestamp "yesterday"`},
+		{"missing SD", "<1>1 - - - - - msg", "missing structured data"},
+		{"text after SD", "<1>1 - - - - - -msg", "missing space before message"},
+		{"empty SD-ID", "<1>1 - - - - - [ a=\"b\"]", "empty SD-ID"},
+		{"SD-ID without close", "<1>1 - - - - - [id", "bad SD-PARAM"},
+		{"param without value", "<1>1 - - - - - [id a]", "bad SD-PARAM"},
+		{"param name with quote", `<1>1 - - - - - [id a"b="c"]`, "bad SD-PARAM"},
+		{"unterminated param", `<1>1 - - - - - [id a="b\"]`, "unterminated SD-PARAM"},
+		{"unterminated element", `<1>1 - - - - - [id a="b"`, "unterminated SD-ELEMENT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRFC5424(tt.line)
+			if !errors.Is(err, ErrInvalidSyslog) {
+				t.Fatalf("Expected ErrInvalidSyslog, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expected error to contain %q, got %q", tt.msg, err.Error())
+			}
+		})
+	}
+}
+
+func TestParseRFC3164(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		app      string
+		procID   string
+		message  string
+		severity SyslogSeverity
+	}{
+		{"tag with pid", "<13>Oct 11 22:14:15 mymachine su[123]: 'su root' failed\n", "su", "123", "'su root' failed", SeverityNotice},
+		{"tag only", "<11>Oct  1 22:14:15 mymachine kernel: link down", "kernel", "", "link down", SeverityError},
+		{"no tag", "<14>Oct 11 22:14:15 mymachine just a message: with colon", "", "", "just a message: with colon", SeverityInfo},
+		{"unclosed pid", "<14>Oct 11 22:14:15 mymachine app[12: msg", "app[12", "", "msg", SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseRFC3164(tt.line)
+			if err != nil {
+				t.Fatalf("ParseRFC3164 failed: %v", err)
+			}
+			if m.Hostname != "mymachine" || m.AppName != tt.app || m.ProcID != tt.procID || m.Message != tt.message {
+				t.Errorf("Unexpected fields: %+v", m)
+			}
+			if m.Severity != tt.severity || m.Facility != 1 || m.Version != 0 {
+				t.Errorf("Unexpected priority fields: %+v", m)
+			}
+			if m.Timestamp.Month() != time.October || m.Timestamp.Hour() != 22 {
+				t.Errorf("Unexpected timestamp %v", m.Timestamp)
+			}
+		})
+	}
+}
+
+func TestParseRFC3164_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		msg  string
+	}{
+		{"no priority", "Oct 11 22:14:15 host app: msg", "missing priority"},
+		{"short timestamp", "<13>Oct 11", "missing timestamp"},
+		{"bad timestamp", "<13>Foo 11 22:14:15 host app: msg", "bad timestamp"},
+		{"no hostname", "<13>Oct 11 22:14:15 host", "missing hostname"},
+		{"empty hostname", "<13>Oct 11 22:14:15  app: msg", "missing hostname"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRFC3164(tt.line)
+			if !errors.Is(err, ErrInvalidSyslog) {
+				t.Fatalf("Expected ErrInvalidSyslog, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expected error to contain %q, got %q", tt.msg, err.Error())
+			}
+		})
+	}
+}
+
+func TestWithYear(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		ts       time.Time
+		expected time.Time
+	}{
+		{"same year", time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"tomorrow is kept", time.Date(0, 1, 2, 9, 0, 0, 0, time.UTC), time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"december is last year", time.Date(0, 12, 31, 23, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withYear(tt.ts, now); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseSyslog(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		version int
+		wantErr bool
+	}{
+		{"rfc5424", "<34>1 - host app - - - msg", 1, false},
+		{"rfc3164", "<34>Oct 11 22:14:15 host app: msg", 0, false},
+		{"bad priority", "<999>1 - - - - - -", 0, true},
+		{"digits then text is rfc3164", "<34>1a - - - - - -", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseSyslog(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSyslog failed: %v", err)
+			}
+			if m.Version != tt.version {
+				t.Errorf("Expected version %d, got %d", tt.version, m.Version)
+			}
+		})
+	}
+}
+
+func TestSyslogToJSONFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{
+			name:     "rfc5424",
+			in:       `<165>1 2003-10-11T22:14:15.003Z host app 42 ID47 [id@1 k="v"] hello` + "\n",
+			expected: `{"priority":165,"facility":20,"severity":5,"version":1,"timestamp":"2003-10-11T22:14:15.003Z","hostname":"host","app_name":"app","procid":"42","msgid":"ID47","structured_data":{"id@1":{"k":"v"}},"message":"hello"}` + "\n",
+		},
+		{
+			name:     "nil values omitted",
+			in:       "<0>1 - - - - - -",
+			expected: `{"priority":0,"facility":0,"severity":0,"version":1,"message":""}`,
+		},
+		{"invalid dropped", "not syslog\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SyslogToJSONFilter(tt.in)
+			if err != nil {
+				t.Fatalf("Filter failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewSyslogSeverityFilter(t *testing.T) {
+	data := "<11>Oct 11 22:14:15 host app: error\n" +
+		"<14>Oct 11 22:14:15 host app: info\n" +
+		"<12>1 - host app - - - warning\n" +
+		"garbage\n"
+
+	out, err := io.ReadAll(NewStreamReader(strings.NewReader(data), NewSyslogSeverityFilter(SeverityWarning)))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	expected := "<11>Oct 11 22:14:15 host app: error\n<12>1 - host app - - - warning\n"
+	if string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(out))
+	}
+}
+
+func TestScanOctetCounted(t *testing.T) {
+	frame := func(msg string) string { return strconv.Itoa(len(msg)) + " " + msg }
+	data := frame("<34>1 - host app - - - first\n") +
+		frame("<34>1 - host app - - - second\nline") + "\n" +
+		frame("")
+
+	sr := NewStreamReader(strings.NewReader(data), nil, WithSplitFunc(ScanOctetCounted))
+	out, err := io.ReadAll(sr)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	expected := "<34>1 - host app - - - first\n<34>1 - host app - - - second\nline\n\n"
+	if string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(out))
+	}
+}
+
+func TestScanOctetCounted_Split(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		atEOF   bool
+		advance int
+		token   string
+		msg     string
+	}{
+		{"empty", "", true, 0, "", ""},
+		{"only whitespace", " \r\n", false, 3, "", ""},
+		{"partial length", "\n12", false, 1, "", ""},
+		{"partial message", "10 abc", false, 0, "", ""},
+		{"complete", "3 abc4 defg", false, 5, "abc\n", ""},
+		{"keeps newline", "4 abc\n", true, 6, "abc\n", ""},
+		{"truncated length", "12", true, 0, "", "truncated length"},
+		{"truncated message", "10 abc", true, 0, "", "truncated message"},
+		{"not a number", "abc def", false, 0, "", "bad length prefix"},
+		{"missing space", "3\nabc", false, 0, "", "bad length prefix"},
+		{"length too long", "1234567890 x", false, 0, "", "bad length prefix"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			advance, token, err := ScanOctetCounted([]byte(tt.data), tt.atEOF)
+			if tt.msg != "" {
+				if !errors.Is(err, ErrInvalidFrame) || !strings.Contains(err.Error(), tt.msg) {
+					t.Errorf("Expected ErrInvalidFrame with %q, got %v", tt.msg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if advance != tt.advance || string(token) != tt.token {
+				t.Errorf("Expected (%d, %q), got (%d, %q)", tt.advance, tt.token, advance, string(token))
+			}
+		})
+	}
+}
+
+func TestScanOctetCounted_DoesNotAliasInput(t *testing.T) {
+	data := []byte("3 abcX")
+	_, token, _ := ScanOctetCounted(data, false)
+	if string(data) != "3 abcX" {
+		t.Errorf("Input was modified: %q", string(data))
+	}
+	if string(token) != "abc\n" {
+		t.Errorf("Expected %q, got %q", "abc\n", string(token))
+	}
+}
+
+func TestScanOctetCounted_EmbeddedNewline(t *testing.T) {
+	msg := "<13>Oct 11 22:14:15 host app: multi\nline"
+	data := "40 " + msg
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Split(ScanOctetCounted)
+	if !scanner.Scan() {
+		t.Fatalf("Scan failed: %v", scanner.Err())
+	}
+	if scanner.Text() != msg+"\n" {
+		t.Errorf("Expected %q, got %q", msg+"\n", scanner.Text())
+	}
+}
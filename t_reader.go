@@ -5,16 +5,22 @@ import "io"
 type TeeReaderCloser struct {
 	reader io.Reader
 	closer io.Closer
+	state  closeState
 }
 
 func (t *TeeReaderCloser) Read(p []byte) (n int, err error) {
+	if t.state.isClosed() {
+		return 0, ErrClosed
+	}
 	return t.reader.Read(p)
 }
 
 func (t *TeeReaderCloser) Close() error {
-	return t.closer.Close()
+	return t.state.close(t.closer.Close)
 }
 
 func NewTeeReaderCloser(r io.ReadCloser, w io.Writer) *TeeReaderCloser {
-	return &TeeReaderCloser{reader: io.TeeReader(r, w), closer: r}
+	t := &TeeReaderCloser{reader: io.TeeReader(r, w), closer: r}
+	t.state.track("TeeReaderCloser")
+	return t
 }
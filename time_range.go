@@ -0,0 +1,178 @@
+package go_sio
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"io"
+	"slices"
+	"time"
+)
+
+// timeSearchLinear is the span below which the binary search stops and the
+// rest is scanned line by line.
+const timeSearchLinear = 64 * 1024
+
+// TimestampFunc extracts the timestamp of a line. It reports false for lines
+// without one, such as the continuation lines of a stack trace.
+type TimestampFunc func(line string) (time.Time, bool)
+
+// TimeRangeReader reads the lines of a time-sorted log whose timestamps fall
+// in [since, until). It binary-searches the input for the first such line
+// instead of scanning from the start, and stops at the first line at or
+// after until. Lines without a timestamp belong to the timestamped line
+// before them.
+//
+// The timestamps met by the search are checked for order. If they are out
+// of order the whole input is scanned instead, keeping every line in the
+// range. Disorder found while streaming turns off the early stop for the
+// rest of the input.
+type TimeRangeReader struct {
+	r            io.ReaderAt
+	size         int64
+	since, until time.Time
+	timestamp    TimestampFunc
+	cursor       *lineCursor
+	sorted       bool
+	keep         bool
+	last         time.Time
+	buffer       bytes.Buffer
+	err          error
+}
+
+// NewTimeRangeReader reads size bytes of r. A zero until means no upper
+// bound. The search runs on the first Read. Returns nil when r or ts is nil.
+func NewTimeRangeReader(r io.ReaderAt, size int64, since, until time.Time, ts TimestampFunc) *TimeRangeReader {
+	if r == nil || ts == nil {
+		return nil
+	}
+	return &TimeRangeReader{r: r, size: size, since: since, until: until, timestamp: ts}
+}
+
+// Sorted reports whether the input still looks sorted: false once the
+// reader has fallen back to scanning.
+func (t *TimeRangeReader) Sorted() bool {
+	return t != nil && t.sorted
+}
+
+func (t *TimeRangeReader) Read(p []byte) (n int, err error) {
+	if t == nil {
+		return 0, ErrNilReader
+	}
+	if t.cursor == nil && t.err == nil {
+		start, sorted, err := t.search()
+		t.cursor, t.sorted, t.err = newLineCursor(t.r, start, t.size), sorted, err
+	}
+
+	for t.buffer.Len() == 0 && t.err == nil {
+		line, _, err := t.cursor.next()
+		if err != nil {
+			t.err = err
+			break
+		}
+		if ts, ok := t.timestamp(line); ok {
+			if ts.Before(t.last) {
+				t.sorted = false
+			}
+			t.last = ts
+			if t.sorted && !t.beforeUntil(ts) {
+				t.err = io.EOF
+				break
+			}
+			t.keep = !ts.Before(t.since) && t.beforeUntil(ts)
+		}
+		if t.keep {
+			t.buffer.WriteString(line)
+		}
+	}
+	if t.buffer.Len() > 0 {
+		return t.buffer.Read(p)
+	}
+	return 0, t.err
+}
+
+func (t *TimeRangeReader) beforeUntil(ts time.Time) bool {
+	return t.until.IsZero() || ts.Before(t.until)
+}
+
+type timeProbe struct {
+	offset int64
+	ts     time.Time
+}
+
+// search returns the offset of a line at or before the first line of the
+// range, and whether the timestamps probed on the way were in order. When
+// they were not, it returns 0.
+func (t *TimeRangeReader) search() (int64, bool, error) {
+	lo, hi := int64(0), t.size
+	var probes []timeProbe
+	for hi-lo > timeSearchLinear {
+		mid := lo + (hi-lo)/2
+		line, start, ts, ok, err := t.firstTimed(mid, hi)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			probes = append(probes, timeProbe{start, ts})
+		}
+		if ok && ts.Before(t.since) {
+			lo = start + int64(len(line))
+		} else {
+			// Either no timestamp in [mid, hi) or the range starts
+			// before the probed line; both leave the answer below mid or
+			// found by scanning on from lo
+			hi = mid
+		}
+	}
+
+	slices.SortFunc(probes, func(a, b timeProbe) int { return cmp.Compare(a.offset, b.offset) })
+	for i := 1; i < len(probes); i++ {
+		if probes[i].ts.Before(probes[i-1].ts) {
+			return 0, false, nil
+		}
+	}
+	return lo, true, nil
+}
+
+// firstTimed finds the first line with a timestamp that starts in
+// [from, limit), after realigning from to the next line start.
+func (t *TimeRangeReader) firstTimed(from, limit int64) (line string, start int64, ts time.Time, ok bool, err error) {
+	c := newLineCursor(t.r, from-1, t.size)
+	if _, _, err := c.next(); err != nil {
+		return "", 0, time.Time{}, false, err
+	}
+	for {
+		line, start, err = c.next()
+		if err == io.EOF || start >= limit {
+			return "", 0, time.Time{}, false, nil
+		}
+		if err != nil {
+			return "", 0, time.Time{}, false, err
+		}
+		if ts, ok = t.timestamp(line); ok {
+			return line, start, ts, true, nil
+		}
+	}
+}
+
+// lineCursor reads the lines of an io.ReaderAt with their offsets.
+type lineCursor struct {
+	br  *bufio.Reader
+	off int64
+}
+
+func newLineCursor(r io.ReaderAt, off, size int64) *lineCursor {
+	return &lineCursor{br: bufio.NewReader(io.NewSectionReader(r, off, size-off)), off: off}
+}
+
+// next returns the next line, with its terminator, and its offset. A final
+// line without a newline is returned with a nil error and io.EOF follows.
+func (c *lineCursor) next() (string, int64, error) {
+	start := c.off
+	line, err := c.br.ReadString('\n')
+	c.off += int64(len(line))
+	if err != nil && (err != io.EOF || line == "") {
+		return "", start, err
+	}
+	return line, start, nil
+}
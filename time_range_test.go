@@ -0,0 +1,222 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var rangeBase = time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)
+
+// leadingTime parses an RFC 3339 timestamp before the first space
+func leadingTime(line string) (time.Time, bool) {
+	field, _, _ := strings.Cut(line, " ")
+	ts, err := time.Parse(time.RFC3339, field)
+	return ts, err == nil
+}
+
+// sortedLog returns n lines one second apart, with a continuation line after
+// every tenth one.
+func sortedLog(n int) string {
+	var sb strings.Builder
+	for i := range n {
+		sb.WriteString(rangeBase.Add(time.Duration(i)*time.Second).Format(time.RFC3339) + " request " + strconv.Itoa(i) + "\n")
+		if i%10 == 0 {
+			sb.WriteString("\tat frame " + strconv.Itoa(i) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// scanRange filters data the slow way, for comparison.
+func scanRange(data string, since, until time.Time) string {
+	var sb strings.Builder
+	keep := false
+	for _, line := range strings.SplitAfter(data, "\n") {
+		if ts, ok := leadingTime(line); ok {
+			keep = !ts.Before(since) && (until.IsZero() || ts.Before(until))
+		}
+		if keep {
+			sb.WriteString(line)
+		}
+	}
+	return sb.String()
+}
+
+// countingReaderAt counts the bytes read through it
+type countingReaderAt struct {
+	r     io.ReaderAt
+	bytes atomic.Int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.bytes.Add(int64(n))
+	return n, err
+}
+
+func TestNewTimeRangeReader(t *testing.T) {
+	if tr := NewTimeRangeReader(nil, 0, time.Time{}, time.Time{}, leadingTime); tr != nil {
+		t.Error("Expected nil TimeRangeReader for nil reader")
+	}
+	if tr := NewTimeRangeReader(strings.NewReader(""), 0, time.Time{}, time.Time{}, nil); tr != nil {
+		t.Error("Expected nil TimeRangeReader for nil TimestampFunc")
+	}
+	var tr *TimeRangeReader
+	if _, err := tr.Read(make([]byte, 1)); err != ErrNilReader {
+		t.Errorf("Expected ErrNilReader, got %v", err)
+	}
+	if tr.Sorted() {
+		t.Error("Expected Sorted to be false for nil TimeRangeReader")
+	}
+}
+
+func TestTimeRangeReader_Sorted(t *testing.T) {
+	data := sortedLog(20000)
+	at := func(sec int) time.Time { return rangeBase.Add(time.Duration(sec) * time.Second) }
+
+	tests := []struct {
+		name         string
+		since, until time.Time
+	}{
+		{"middle window", at(8000), at(8480)},
+		{"between seconds", at(8000).Add(500 * time.Millisecond), at(8010).Add(time.Millisecond)},
+		{"from the start", time.Time{}, at(30)},
+		{"to the end", at(19990), time.Time{}},
+		{"before the log", at(-100), at(-50)},
+		{"after the log", at(30000), at(40000)},
+		{"empty window", at(100), at(100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := &countingReaderAt{r: strings.NewReader(data)}
+			tr := NewTimeRangeReader(counter, int64(len(data)), tt.since, tt.until, leadingTime)
+			out, err := io.ReadAll(tr)
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			if expected := scanRange(data, tt.since, tt.until); string(out) != expected {
+				t.Errorf("Expected %d bytes, got %d", len(expected), len(out))
+			}
+			if !tr.Sorted() {
+				t.Error("Expected the log to be treated as sorted")
+			}
+			if tt.name == "middle window" && counter.bytes.Load() > int64(len(data))/4 {
+				t.Errorf("Expected the search to skip most of the %d bytes, read %d", len(data), counter.bytes.Load())
+			}
+		})
+	}
+}
+
+func TestTimeRangeReader_ContinuationLines(t *testing.T) {
+	data := sortedLog(20000)
+	since := rangeBase.Add(12340 * time.Second)
+	tr := NewTimeRangeReader(strings.NewReader(data), int64(len(data)), since, since.Add(2*time.Second), leadingTime)
+	out, _ := io.ReadAll(tr)
+	expected := since.Format(time.RFC3339) + " request 12340\n\tat frame 12340\n" +
+		since.Add(time.Second).Format(time.RFC3339) + " request 12341\n"
+	if string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(out))
+	}
+}
+
+func TestTimeRangeReader_Unsorted(t *testing.T) {
+	// Swap two large blocks so the search probes see time going backwards
+	lines := strings.SplitAfter(sortedLog(20000), "\n")
+	third := len(lines) / 3
+	data := strings.Join(lines[2*third:], "") + strings.Join(lines[third:2*third], "") + strings.Join(lines[:third], "")
+
+	since, until := rangeBase.Add(1000*time.Second), rangeBase.Add(1100*time.Second)
+	tr := NewTimeRangeReader(strings.NewReader(data), int64(len(data)), since, until, leadingTime)
+	out, err := io.ReadAll(tr)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if expected := scanRange(data, since, until); string(out) != expected || len(out) == 0 {
+		t.Errorf("Expected %d bytes, got %d", len(expected), len(out))
+	}
+	if tr.Sorted() {
+		t.Error("Expected the log to be detected as unsorted")
+	}
+}
+
+func TestTimeRangeReader_DisorderWhileStreaming(t *testing.T) {
+	at := func(sec int) string { return rangeBase.Add(time.Duration(sec)*time.Second).Format(time.RFC3339) + " " }
+	data := at(1) + "a\n" + at(5) + "b\n" + at(3) + "c\n" + at(9) + "d\n" + at(4) + "e"
+	tr := NewTimeRangeReader(strings.NewReader(data), int64(len(data)), rangeBase.Add(3*time.Second), rangeBase.Add(6*time.Second), leadingTime)
+	out, _ := io.ReadAll(tr)
+	if expected := at(5) + "b\n" + at(3) + "c\n" + at(4) + "e"; string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(out))
+	}
+	if tr.Sorted() {
+		t.Error("Expected the log to be detected as unsorted")
+	}
+}
+
+// failingReaderAt fails reads that reach past limit
+type failingReaderAt struct {
+	r     io.ReaderAt
+	limit int64
+	err   error
+}
+
+func (f *failingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off+int64(len(p)) > f.limit {
+		if off >= f.limit {
+			return 0, f.err
+		}
+		n, _ := f.r.ReadAt(p[:f.limit-off], off)
+		return n, f.err
+	}
+	return f.r.ReadAt(p, off)
+}
+
+func TestTimeRangeReader_ReadErrors(t *testing.T) {
+	data := sortedLog(20000)
+	readErr := errors.New("read error")
+	size := int64(len(data))
+
+	tests := []struct {
+		name  string
+		limit int64
+		since time.Time
+	}{
+		{"realigning", size/2 - 10, rangeBase.Add(19000 * time.Second)},
+		{"probing", size/2 + 10, rangeBase.Add(19000 * time.Second)},
+		{"streaming", size - 10, rangeBase.Add(19990 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &failingReaderAt{r: strings.NewReader(data), limit: tt.limit, err: readErr}
+			tr := NewTimeRangeReader(r, size, tt.since, time.Time{}, leadingTime)
+			if _, err := io.ReadAll(tr); err != readErr {
+				t.Errorf("Expected %v, got %v", readErr, err)
+			}
+		})
+	}
+}
+
+func TestTimeRangeReader_LongUntimedBlock(t *testing.T) {
+	// Probes that land in the block find no timestamp before the search bound
+	lines := strings.SplitAfter(sortedLog(4000), "\n")
+	half := len(lines) / 2
+	data := strings.Join(lines[:half], "") + strings.Repeat("\tat deep.frame(Native Method)\n", 8000) + strings.Join(lines[half:], "")
+
+	for _, sec := range []int{100, 1900, 3500} {
+		since := rangeBase.Add(time.Duration(sec) * time.Second)
+		tr := NewTimeRangeReader(strings.NewReader(data), int64(len(data)), since, since.Add(20*time.Second), leadingTime)
+		out, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("ReadAll failed: %v", err)
+		}
+		if expected := scanRange(data, since, since.Add(20*time.Second)); string(out) != expected || len(out) == 0 {
+			t.Errorf("Window at %d: expected %d bytes, got %d", sec, len(expected), len(out))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	ErrIdleTimeout      = errors.New("idle timeout")
+	ErrDeadlineExceeded = errors.New("deadline exceeded")
+)
+
+// TimeoutReadCloser closes its inner stream and fails Read when no bytes
+// arrive within the idle window while a Read is waiting, or when the overall
+// deadline passes. Closing the inner stream is what unblocks a pending Read,
+// so the inner Close must be safe to call concurrently with Read, as it is
+// for files, pipes and HTTP bodies.
+type TimeoutReadCloser struct {
+	rc        io.ReadCloser
+	idle      time.Duration
+	mu        sync.Mutex
+	idleTimer *time.Timer
+	deadline  *time.Timer
+	failure   error
+	inner     sync.Once
+	innerErr  error
+	state     closeState
+}
+
+// NewTimeoutReadCloser wraps rc with an idle timeout and a total deadline
+// measured from now. A zero or negative duration disables that limit.
+func NewTimeoutReadCloser(rc io.ReadCloser, idle, total time.Duration) *TimeoutReadCloser {
+	t := &TimeoutReadCloser{rc: rc, idle: idle}
+	if total > 0 {
+		t.deadline = time.AfterFunc(total, func() { t.expire(ErrDeadlineExceeded) })
+	}
+	t.state.track("TimeoutReadCloser")
+	return t
+}
+
+func (t *TimeoutReadCloser) Read(p []byte) (n int, err error) {
+	if t.state.isClosed() {
+		return 0, ErrClosed
+	}
+	if err := t.failed(); err != nil {
+		return 0, err
+	}
+	if t.idle > 0 {
+		t.mu.Lock()
+		if t.idleTimer == nil {
+			t.idleTimer = time.AfterFunc(t.idle, func() { t.expire(ErrIdleTimeout) })
+		} else {
+			t.idleTimer.Reset(t.idle)
+		}
+		t.mu.Unlock()
+	}
+
+	n, err = t.rc.Read(p)
+
+	if t.idle > 0 {
+		t.mu.Lock()
+		t.idleTimer.Stop()
+		t.mu.Unlock()
+	}
+	if ferr := t.failed(); ferr != nil {
+		return n, ferr
+	}
+	return n, err
+}
+
+func (t *TimeoutReadCloser) Close() error {
+	return t.state.close(func() error {
+		t.mu.Lock()
+		if t.idleTimer != nil {
+			t.idleTimer.Stop()
+		}
+		t.mu.Unlock()
+		if t.deadline != nil {
+			t.deadline.Stop()
+		}
+		return t.closeInner()
+	})
+}
+
+func (t *TimeoutReadCloser) failed() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failure
+}
+
+func (t *TimeoutReadCloser) expire(err error) {
+	t.mu.Lock()
+	if t.failure == nil {
+		t.failure = err
+	}
+	t.mu.Unlock()
+	_ = t.closeInner()
+}
+
+func (t *TimeoutReadCloser) closeInner() error {
+	t.inner.Do(func() { t.innerErr = t.rc.Close() })
+	return t.innerErr
+}
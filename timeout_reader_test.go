@@ -0,0 +1,121 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestTimeoutReadCloser_IdleTimeout(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	tr := NewTimeoutReadCloser(pr, 20*time.Millisecond, 0)
+	defer tr.Close()
+
+	go func() { _, _ = pw.Write([]byte("line\n")) }()
+	buf := make([]byte, 16)
+	n, err := tr.Read(buf)
+	if err != nil || string(buf[:n]) != "line\n" {
+		t.Fatalf("Expected %q, got %q (%v)", "line\n", string(buf[:n]), err)
+	}
+
+	// Nothing else is written, so the next Read must time out
+	n, err = tr.Read(buf)
+	if err != ErrIdleTimeout {
+		t.Errorf("Expected ErrIdleTimeout, got %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected 0 bytes on timeout, got %d", n)
+	}
+	// The inner pipe is closed, so the writer side fails
+	if _, err := pw.Write([]byte("late\n")); err != io.ErrClosedPipe {
+		t.Errorf("Expected writer to see ErrClosedPipe, got %v", err)
+	}
+	// The failure is sticky
+	if _, err := tr.Read(buf); err != ErrIdleTimeout {
+		t.Errorf("Expected ErrIdleTimeout again, got %v", err)
+	}
+}
+
+func TestTimeoutReadCloser_IdleResetsPerRead(t *testing.T) {
+	pr, pw := io.Pipe()
+	tr := NewTimeoutReadCloser(pr, 50*time.Millisecond, 0)
+	defer tr.Close()
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			time.Sleep(10 * time.Millisecond)
+			_, _ = pw.Write([]byte("tick\n"))
+		}
+		_ = pw.Close()
+	}()
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(data) != 25 {
+		t.Errorf("Expected 25 bytes, got %d", len(data))
+	}
+}
+
+func TestTimeoutReadCloser_Deadline(t *testing.T) {
+	pr, pw := io.Pipe()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+				if _, err := pw.Write([]byte("tick\n")); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	rc := NewJSONFilterReadCloser(NewTimeoutReadCloser(pr, time.Second, 40*time.Millisecond))
+	defer rc.Close()
+	_, err := io.ReadAll(rc)
+	if err != ErrDeadlineExceeded {
+		t.Errorf("Expected ErrDeadlineExceeded, got %v", err)
+	}
+}
+
+func TestTimeoutReadCloser_Close(t *testing.T) {
+	expectedErr := errors.New("close error")
+	inner := &countingCloser{err: expectedErr}
+	tr := NewTimeoutReadCloser(NewReadCloser(&mockReader{data: "abc"}, inner), time.Millisecond, 10*time.Millisecond)
+
+	data, err := io.ReadAll(tr)
+	if err != nil || string(data) != "abc" {
+		t.Fatalf("Expected %q, got %q (%v)", "abc", string(data), err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := tr.Close(); err != expectedErr {
+			t.Errorf("Expected %v, got %v", expectedErr, err)
+		}
+	}
+	if _, err := tr.Read(make([]byte, 1)); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+
+	// The stopped deadline must not close the inner stream again
+	time.Sleep(20 * time.Millisecond)
+	if got := inner.calls.Load(); got != 1 {
+		t.Errorf("Expected inner Close once, got %d", got)
+	}
+}
+
+func TestTimeoutReadCloser_NoLimits(t *testing.T) {
+	tr := NewTimeoutReadCloser(newMockReadCloser("abc"), 0, 0)
+	data, err := io.ReadAll(tr)
+	if err != nil || string(data) != "abc" {
+		t.Errorf("Expected %q, got %q (%v)", "abc", string(data), err)
+	}
+	if err := tr.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
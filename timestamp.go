@@ -0,0 +1,172 @@
+package go_sio
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apacheErrorTimeLayout is the Apache error log timestamp. Fractional
+// seconds after the seconds field are accepted when parsing.
+const apacheErrorTimeLayout = "Mon Jan _2 15:04:05 2006"
+
+// RFC3339Timestamp reads an RFC 3339 timestamp, with or without fractional
+// seconds down to nanoseconds, from the first field of a line. The field may
+// be wrapped in square brackets.
+var RFC3339Timestamp TimestampFunc = func(line string) (time.Time, bool) {
+	ts, err := time.Parse(time.RFC3339Nano, leadingField(line))
+	return ts, err == nil
+}
+
+// SyslogTimestamp reads the timestamp of an RFC 5424 or RFC 3164 message,
+// with or without the "<PRI>" prefix, as written by syslog daemons to files
+// ("Oct 11 22:14:15 host ..." or "2024-05-01T14:02:00.123+02:00 host ...").
+// Year-less timestamps are placed as by ParseRFC3164.
+var SyslogTimestamp TimestampFunc = func(line string) (time.Time, bool) {
+	if strings.HasPrefix(line, "<") {
+		m, err := ParseSyslog(line)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return m.Timestamp, !m.Timestamp.IsZero()
+	}
+	if len(line) > len(time.Stamp) && line[len(time.Stamp)] == ' ' {
+		if ts, err := time.ParseInLocation(time.Stamp, line[:len(time.Stamp)], time.Local); err == nil {
+			return withYear(ts, time.Now()), true
+		}
+	}
+	return RFC3339Timestamp(line)
+}
+
+// ApacheTimestamp reads the first bracketed timestamp of a line in the
+// access log format ("[10/Oct/2000:13:55:36 -0700]") or the error log format
+// ("[Wed Oct 11 14:32:52.123456 2000]", in local time).
+var ApacheTimestamp TimestampFunc = func(line string) (time.Time, bool) {
+	_, rest, ok := strings.Cut(line, "[")
+	field, _, closed := strings.Cut(rest, "]")
+	if !ok || !closed {
+		return time.Time{}, false
+	}
+	if ts, err := time.Parse(accessLogTimeLayout, field); err == nil {
+		return ts, true
+	}
+	ts, err := time.ParseInLocation(apacheErrorTimeLayout, field, time.Local)
+	return ts, err == nil
+}
+
+// EpochSecondsTimestamp reads Unix seconds, optionally with a fraction
+// ("1714572120.250"), from the first field of a line.
+var EpochSecondsTimestamp TimestampFunc = func(line string) (time.Time, bool) {
+	return parseEpochSeconds(leadingField(line))
+}
+
+// EpochMillisTimestamp reads Unix milliseconds from the first field of a
+// line.
+var EpochMillisTimestamp TimestampFunc = func(line string) (time.Time, bool) {
+	ms, err := strconv.ParseInt(leadingField(line), 10, 64)
+	return time.UnixMilli(ms), err == nil
+}
+
+// JSONFieldTimestamp returns a TimestampFunc that reads the field at a
+// dot-separated path, such as "ts" or "meta.time", of JSON object lines.
+// String values are parsed as RFC 3339. Numbers are Unix seconds, or Unix
+// milliseconds when they are integers of 1e11 or more (later than the year
+// 5000 as seconds).
+func JSONFieldTimestamp(path string) TimestampFunc {
+	keys := strings.Split(path, ".")
+	return func(line string) (time.Time, bool) {
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.UseNumber()
+		var value any
+		if dec.Decode(&value) != nil {
+			return time.Time{}, false
+		}
+		for _, key := range keys {
+			obj, ok := value.(map[string]any)
+			if !ok {
+				return time.Time{}, false
+			}
+			value = obj[key]
+		}
+
+		switch v := value.(type) {
+		case string:
+			ts, err := time.Parse(time.RFC3339Nano, v)
+			return ts, err == nil
+		case json.Number:
+			if ms, err := v.Int64(); err == nil && (ms >= 1e11 || ms <= -1e11) {
+				return time.UnixMilli(ms), true
+			}
+			return parseEpochSeconds(v.String())
+		}
+		return time.Time{}, false
+	}
+}
+
+// leadingField returns the first space-separated field of a line without
+// surrounding square brackets.
+func leadingField(line string) string {
+	line, _ = cutLineEnd(line)
+	field, _, _ := strings.Cut(line, " ")
+	if strings.HasPrefix(field, "[") && strings.HasSuffix(field, "]") {
+		field = field[1 : len(field)-1]
+	}
+	return field
+}
+
+// parseEpochSeconds parses "seconds[.fraction]" without going through a
+// float, so nanoseconds survive.
+func parseEpochSeconds(s string) (time.Time, bool) {
+	secStr, frac, hasFrac := strings.Cut(s, ".")
+	sec, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil || (hasFrac && (frac == "" || len(frac) > 9 || strings.Trim(frac, "0123456789") != "")) {
+		return time.Time{}, false
+	}
+	var nsec int64
+	if hasFrac {
+		nsec, _ = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64) // nine digits at most
+	}
+	if strings.HasPrefix(secStr, "-") {
+		nsec = -nsec
+	}
+	return time.Unix(sec, nsec), true
+}
+
+// UntimedPolicy selects what NewTimeWindowFilter does with lines that have
+// no timestamp.
+type UntimedPolicy int
+
+const (
+	// UntimedAttach keeps or drops a line with the timestamped line before
+	// it, so multi-line records such as stack traces stay whole. Untimed
+	// lines before the first timestamped line are dropped.
+	UntimedAttach UntimedPolicy = iota
+	// UntimedKeep always keeps untimed lines.
+	UntimedKeep
+	// UntimedDrop always drops untimed lines.
+	UntimedDrop
+)
+
+// NewTimeWindowFilter returns a StringLineFilter that keeps lines whose
+// timestamp, read with ts, falls in [since, until). A zero until means no
+// upper bound. Lines without a timestamp are handled by untimed. With
+// UntimedAttach the filter keeps state and is not safe for concurrent use.
+func NewTimeWindowFilter(ts TimestampFunc, since, until time.Time, untimed UntimedPolicy) StringLineFilter {
+	keep := false
+	return func(in string) (string, error) {
+		t, ok := ts(in)
+		switch {
+		case ok:
+			keep = !t.Before(since) && (until.IsZero() || t.Before(until))
+		case untimed == UntimedKeep:
+			return in, nil
+		case untimed == UntimedDrop:
+			return "", nil
+		}
+		if keep {
+			return in, nil
+		}
+		return "", nil
+	}
+}
@@ -0,0 +1,129 @@
+package go_sio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestampExtractors(t *testing.T) {
+	utc := func(s string) time.Time {
+		ts, _ := time.Parse(time.RFC3339Nano, s)
+		return ts
+	}
+	jsonTS := JSONFieldTimestamp("meta.time")
+
+	tests := []struct {
+		name     string
+		fn       TimestampFunc
+		line     string
+		expected time.Time
+		ok       bool
+	}{
+		{"rfc3339", RFC3339Timestamp, "2024-05-01T14:02:00Z level=info\n", utc("2024-05-01T14:02:00Z"), true},
+		{"rfc3339 nano", RFC3339Timestamp, "2024-05-01T14:02:00.123456789+02:00 msg", utc("2024-05-01T12:02:00.123456789Z"), true},
+		{"rfc3339 bracketed", RFC3339Timestamp, "[2024-05-01T14:02:00Z] INFO", utc("2024-05-01T14:02:00Z"), true},
+		{"rfc3339 only field", RFC3339Timestamp, "2024-05-01T14:02:00Z\r\n", utc("2024-05-01T14:02:00Z"), true},
+		{"rfc3339 missing", RFC3339Timestamp, "\tat frame", time.Time{}, false},
+		{"syslog 5424", SyslogTimestamp, "<34>1 2024-05-01T14:02:00.5Z host app - - - msg", utc("2024-05-01T14:02:00.5Z"), true},
+		{"syslog 5424 nil timestamp", SyslogTimestamp, "<34>1 - host app - - - msg", time.Time{}, false},
+		{"syslog bad pri", SyslogTimestamp, "<x>garbage", time.Time{}, false},
+		{"syslog file rfc3339", SyslogTimestamp, "2024-05-01T14:02:00.123+00:00 host sshd[1]: ok", utc("2024-05-01T14:02:00.123Z"), true},
+		{"syslog file bad stamp", SyslogTimestamp, "Foo 11 22:14:15 host su: x", time.Time{}, false},
+		{"apache access", ApacheTimestamp, `1.2.3.4 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 1`, utc("2000-10-10T20:55:36Z"), true},
+		{"apache unclosed", ApacheTimestamp, "[10/Oct/2000:13:55:36 -0700", time.Time{}, false},
+		{"apache no bracket", ApacheTimestamp, "plain", time.Time{}, false},
+		{"apache other bracket", ApacheTimestamp, "[client 1.2.3.4] x", time.Time{}, false},
+		{"epoch seconds", EpochSecondsTimestamp, "1714572120 msg", time.Unix(1714572120, 0), true},
+		{"epoch seconds fraction", EpochSecondsTimestamp, "1714572120.25 msg", time.Unix(1714572120, 250000000), true},
+		{"epoch seconds nanos", EpochSecondsTimestamp, "1714572120.000000001", time.Unix(1714572120, 1), true},
+		{"epoch negative", EpochSecondsTimestamp, "-1.5", time.Unix(-1, -500000000), true},
+		{"epoch empty fraction", EpochSecondsTimestamp, "17.", time.Time{}, false},
+		{"epoch long fraction", EpochSecondsTimestamp, "17.1234567890", time.Time{}, false},
+		{"epoch signed fraction", EpochSecondsTimestamp, "17.+5", time.Time{}, false},
+		{"epoch not a number", EpochSecondsTimestamp, "abc", time.Time{}, false},
+		{"epoch millis", EpochMillisTimestamp, "1714572120250 msg", time.UnixMilli(1714572120250), true},
+		{"epoch millis bad", EpochMillisTimestamp, "1714572120.250", time.Time{}, false},
+		{"json string", jsonTS, `{"meta":{"time":"2024-05-01T14:02:00Z"}}`, utc("2024-05-01T14:02:00Z"), true},
+		{"json seconds", jsonTS, `{"meta":{"time":1714572120.5}}`, time.Unix(1714572120, 500000000), true},
+		{"json millis", jsonTS, `{"meta":{"time":1714572120250}}`, time.UnixMilli(1714572120250), true},
+		{"json bad string", jsonTS, `{"meta":{"time":"yesterday"}}`, time.Time{}, false},
+		{"json bool", jsonTS, `{"meta":{"time":true}}`, time.Time{}, false},
+		{"json missing", jsonTS, `{"meta":{}}`, time.Time{}, false},
+		{"json not an object", jsonTS, `{"meta":[1]}`, time.Time{}, false},
+		{"json invalid", jsonTS, "not json", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.fn(tt.line)
+			if ok != tt.ok || (ok && !got.Equal(tt.expected)) {
+				t.Errorf("Expected %v, %v, got %v, %v", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestTimestampExtractors_LocalTime(t *testing.T) {
+	ts, ok := SyslogTimestamp("Oct 11 22:14:15 host su: 'su root' failed\n")
+	if !ok || ts.Month() != time.October || ts.Day() != 11 || ts.Hour() != 22 || ts.Location() != time.Local {
+		t.Errorf("Unexpected syslog file timestamp %v, %v", ts, ok)
+	}
+	ts, ok = SyslogTimestamp("<34>Oct  1 02:03:04 host app: msg")
+	if !ok || ts.Month() != time.October || ts.Day() != 1 || ts.Second() != 4 {
+		t.Errorf("Unexpected RFC 3164 timestamp %v, %v", ts, ok)
+	}
+	ts, ok = ApacheTimestamp("[Wed Oct 11 14:32:52.123456 2000] [core:error] [pid 35708] AH00037")
+	expected := time.Date(2000, 10, 11, 14, 32, 52, 123456000, time.Local)
+	if !ok || !ts.Equal(expected) {
+		t.Errorf("Expected %v, got %v, %v", expected, ts, ok)
+	}
+}
+
+func TestNewTimeWindowFilter(t *testing.T) {
+	data := "orphan\n" +
+		"2024-05-01T14:00:00Z before\n" +
+		"\tat before.frame\n" +
+		"2024-05-01T14:02:00Z inside\n" +
+		"\tat inside.frame\n" +
+		"2024-05-01T14:09:59.999Z inside end\n" +
+		"2024-05-01T14:10:00Z at until\n" +
+		"\tat until.frame\n"
+	since := time.Date(2024, 5, 1, 14, 2, 0, 0, time.UTC)
+	until := time.Date(2024, 5, 1, 14, 10, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		policy   UntimedPolicy
+		until    time.Time
+		expected string
+	}{
+		{"attach", UntimedAttach, until, "2024-05-01T14:02:00Z inside\n\tat inside.frame\n2024-05-01T14:09:59.999Z inside end\n"},
+		{"keep", UntimedKeep, until, "orphan\n\tat before.frame\n2024-05-01T14:02:00Z inside\n\tat inside.frame\n2024-05-01T14:09:59.999Z inside end\n\tat until.frame\n"},
+		{"drop", UntimedDrop, until, "2024-05-01T14:02:00Z inside\n2024-05-01T14:09:59.999Z inside end\n"},
+		{"no upper bound", UntimedAttach, time.Time{}, "2024-05-01T14:02:00Z inside\n\tat inside.frame\n2024-05-01T14:09:59.999Z inside end\n2024-05-01T14:10:00Z at until\n\tat until.frame\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewTimeWindowFilter(RFC3339Timestamp, since, tt.until, tt.policy)
+			out, err := io.ReadAll(NewStreamReader(strings.NewReader(data), filter))
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(out))
+			}
+		})
+	}
+}
+
+func TestTimestampExtractors_TimeRangeReader(t *testing.T) {
+	data := `{"ts":1714572000,"n":1}` + "\n" + `{"ts":1714572060,"n":2}` + "\n" + `{"ts":1714572120,"n":3}` + "\n"
+	tr := NewTimeRangeReader(strings.NewReader(data), int64(len(data)), time.Unix(1714572060, 0), time.Unix(1714572120, 0), JSONFieldTimestamp("ts"))
+	out, _ := io.ReadAll(tr)
+	if string(out) != `{"ts":1714572060,"n":2}`+"\n" {
+		t.Errorf("Unexpected output %q", string(out))
+	}
+}
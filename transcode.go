@@ -0,0 +1,142 @@
+package go_sio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of a TranscodeReader's input.
+type Encoding int
+
+const (
+	// EncodingAuto detects UTF-8, UTF-16LE or UTF-16BE from a byte order
+	// mark and falls back to UTF-8 without one.
+	EncodingAuto Encoding = iota
+	EncodingUTF8
+	EncodingUTF16LE
+	EncodingUTF16BE
+	// EncodingLatin1 is ISO-8859-1, where every byte is the code point of
+	// the same value.
+	EncodingLatin1
+)
+
+const transcodeChunk = 4096
+
+// TranscodeReader converts UTF-16 or Latin-1 input to UTF-8 so it can be
+// split into lines by a StreamReader.
+type TranscodeReader struct {
+	reader  io.Reader
+	enc     Encoding
+	pending []byte
+	buffer  bytes.Buffer
+	err     error
+}
+
+// NewTranscodeReader decodes r from enc to UTF-8. With EncodingAuto a
+// leading BOM selects the encoding and is removed; with an explicit encoding
+// a BOM is decoded like any other character, and WithStripBOM can remove it.
+// Unpaired UTF-16 surrogates and a trailing odd byte become U+FFFD. UTF-8
+// input is passed through unchanged. Returns nil when r is nil.
+func NewTranscodeReader(r io.Reader, enc Encoding) *TranscodeReader {
+	if r == nil {
+		return nil
+	}
+	return &TranscodeReader{reader: r, enc: enc}
+}
+
+// Encoding returns the input encoding. With EncodingAuto it is only known
+// after the first Read.
+func (t *TranscodeReader) Encoding() Encoding {
+	if t == nil {
+		return EncodingAuto
+	}
+	return t.enc
+}
+
+func (t *TranscodeReader) Read(p []byte) (n int, err error) {
+	if t == nil {
+		return 0, ErrNilReader
+	}
+	chunk := make([]byte, transcodeChunk)
+	for t.buffer.Len() == 0 && t.err == nil {
+		n, err := t.reader.Read(chunk)
+		t.pending = append(t.pending, chunk[:n]...)
+		t.err = err
+		if t.enc == EncodingAuto && len(t.pending) < 3 && err == nil {
+			continue
+		}
+		t.decode(err != nil)
+	}
+	if t.buffer.Len() > 0 {
+		return t.buffer.Read(p)
+	}
+	return 0, t.err
+}
+
+// detect picks the encoding from a BOM and drops the BOM.
+func (t *TranscodeReader) detect() {
+	switch {
+	case bytes.HasPrefix(t.pending, []byte{0xEF, 0xBB, 0xBF}):
+		t.enc, t.pending = EncodingUTF8, t.pending[3:]
+	case bytes.HasPrefix(t.pending, []byte{0xFF, 0xFE}):
+		t.enc, t.pending = EncodingUTF16LE, t.pending[2:]
+	case bytes.HasPrefix(t.pending, []byte{0xFE, 0xFF}):
+		t.enc, t.pending = EncodingUTF16BE, t.pending[2:]
+	default:
+		t.enc = EncodingUTF8
+	}
+}
+
+// decode converts as much of pending as possible. At EOF everything left is
+// flushed.
+func (t *TranscodeReader) decode(eof bool) {
+	if t.enc == EncodingAuto {
+		t.detect()
+	}
+	switch t.enc {
+	case EncodingUTF16LE:
+		t.decodeUTF16(binary.LittleEndian, eof)
+	case EncodingUTF16BE:
+		t.decodeUTF16(binary.BigEndian, eof)
+	case EncodingLatin1:
+		for _, b := range t.pending {
+			t.buffer.WriteRune(rune(b))
+		}
+		t.pending = t.pending[:0]
+	default:
+		t.buffer.Write(t.pending)
+		t.pending = t.pending[:0]
+	}
+}
+
+func (t *TranscodeReader) decodeUTF16(order binary.ByteOrder, eof bool) {
+	in := t.pending
+	for len(in) >= 2 {
+		r := rune(order.Uint16(in))
+		if !utf16.IsSurrogate(r) {
+			t.buffer.WriteRune(r)
+			in = in[2:]
+			continue
+		}
+		if r < 0xDC00 && len(in) < 4 && !eof {
+			break // the low surrogate has not arrived yet
+		}
+		if r < 0xDC00 && len(in) >= 4 {
+			if dec := utf16.DecodeRune(r, rune(order.Uint16(in[2:]))); dec != utf8.RuneError {
+				t.buffer.WriteRune(dec)
+				in = in[4:]
+				continue
+			}
+		}
+		t.buffer.WriteRune(utf8.RuneError)
+		in = in[2:]
+	}
+	if eof && len(in) == 1 {
+		t.buffer.WriteRune(utf8.RuneError)
+		in = nil
+	}
+	t.pending = append(t.pending[:0], in...)
+}
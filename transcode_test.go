@@ -0,0 +1,107 @@
+package go_sio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+func encodeUTF16(s string, order binary.ByteOrder) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, 2*len(units))
+	for i, u := range units {
+		order.PutUint16(out[2*i:], u)
+	}
+	return out
+}
+
+func TestNewTranscodeReader(t *testing.T) {
+	if tr := NewTranscodeReader(nil, EncodingAuto); tr != nil {
+		t.Error("Expected nil TranscodeReader for nil reader")
+	}
+	var tr *TranscodeReader
+	if _, err := tr.Read(make([]byte, 1)); err != ErrNilReader {
+		t.Errorf("Expected ErrNilReader, got %v", err)
+	}
+	if tr.Encoding() != EncodingAuto {
+		t.Errorf("Expected EncodingAuto for nil TranscodeReader, got %v", tr.Encoding())
+	}
+}
+
+func TestTranscodeReader_Read(t *testing.T) {
+	text := "héllo 世界 🎉\r\nline two\n"
+	tests := []struct {
+		name        string
+		data        []byte
+		enc         Encoding
+		expected    string
+		detectedEnc Encoding
+	}{
+		{"utf16le bom", append([]byte{0xFF, 0xFE}, encodeUTF16(text, binary.LittleEndian)...), EncodingAuto, text, EncodingUTF16LE},
+		{"utf16be bom", append([]byte{0xFE, 0xFF}, encodeUTF16(text, binary.BigEndian)...), EncodingAuto, text, EncodingUTF16BE},
+		{"utf8 bom", []byte("\uFEFF" + text), EncodingAuto, text, EncodingUTF8},
+		{"no bom", []byte(text), EncodingAuto, text, EncodingUTF8},
+		{"short input", []byte("a"), EncodingAuto, "a", EncodingUTF8},
+		{"empty", nil, EncodingAuto, "", EncodingUTF8},
+		{"explicit utf16le", encodeUTF16(text, binary.LittleEndian), EncodingUTF16LE, text, EncodingUTF16LE},
+		{"explicit utf16le keeps bom", append([]byte{0xFF, 0xFE}, encodeUTF16("a", binary.LittleEndian)...), EncodingUTF16LE, "\uFEFFa", EncodingUTF16LE},
+		{"explicit utf8", []byte("a\xffb"), EncodingUTF8, "a\xffb", EncodingUTF8},
+		{"latin1", []byte("caf\xe9 \xa9 \xff\n"), EncodingLatin1, "café © ÿ\n", EncodingLatin1},
+		{"lone high surrogate at eof", []byte{'a', 0, 0x3D, 0xD8}, EncodingUTF16LE, "a\uFFFD", EncodingUTF16LE},
+		{"high surrogate without low", []byte{0x3D, 0xD8, 'b', 0}, EncodingUTF16LE, "\uFFFDb", EncodingUTF16LE},
+		{"lone low surrogate", []byte{0x00, 0xDC, 'c', 0}, EncodingUTF16LE, "\uFFFDc", EncodingUTF16LE},
+		{"odd trailing byte", []byte{'a', 0, 'b'}, EncodingUTF16LE, "a\uFFFD", EncodingUTF16LE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, oneByte := range []bool{false, true} {
+				var r io.Reader = strings.NewReader(string(tt.data))
+				if oneByte {
+					r = iotest.OneByteReader(r)
+				}
+				tr := NewTranscodeReader(r, tt.enc)
+				out, err := io.ReadAll(tr)
+				if err != nil {
+					t.Fatalf("ReadAll failed: %v", err)
+				}
+				if string(out) != tt.expected {
+					t.Errorf("Expected %q, got %q (one byte reads: %v)", tt.expected, string(out), oneByte)
+				}
+				if tr.Encoding() != tt.detectedEnc {
+					t.Errorf("Expected encoding %v, got %v", tt.detectedEnc, tr.Encoding())
+				}
+			}
+		})
+	}
+}
+
+func TestTranscodeReader_ReadError(t *testing.T) {
+	readErr := errors.New("read error")
+	tr := NewTranscodeReader(io.MultiReader(strings.NewReader("ab"), iotest.ErrReader(readErr)), EncodingLatin1)
+	out, err := io.ReadAll(tr)
+	if err != readErr {
+		t.Errorf("Expected %v, got %v", readErr, err)
+	}
+	if string(out) != "ab" {
+		t.Errorf("Expected data before the error, got %q", string(out))
+	}
+}
+
+func TestTranscodeReader_StreamReader(t *testing.T) {
+	data := append([]byte{0xFF, 0xFE}, encodeUTF16("{\"a\":1}\r\nnot json\r\n{\"b\":\"ü\"}\r\n", binary.LittleEndian)...)
+	rc := NewJSONFilterReadCloser(io.NopCloser(NewTranscodeReader(strings.NewReader(string(data)), EncodingAuto)))
+	defer rc.Close()
+
+	out, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "{\"a\":1}\r\n{\"b\":\"ü\"}\r\n" {
+		t.Errorf("Unexpected output %q", string(out))
+	}
+}
@@ -0,0 +1,85 @@
+package go_sio
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidUTF8 is matched by errors.Is for every *UTF8Error.
+var ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+const utf8BOM = "\uFEFF"
+
+// UTF8Policy selects how a StreamReader handles lines with invalid UTF-8.
+type UTF8Policy int
+
+const (
+	// UTF8Pass passes lines through unchanged. This is the default.
+	UTF8Pass UTF8Policy = iota
+	// UTF8Replace replaces each run of invalid bytes with U+FFFD.
+	UTF8Replace
+	// UTF8Drop drops lines with invalid UTF-8 before they reach the filter.
+	UTF8Drop
+	// UTF8Fail makes Read return a *UTF8Error.
+	UTF8Fail
+)
+
+// UTF8Error reports the first invalid byte of a line. Line is 1-based and
+// Offset is the byte offset within that line.
+type UTF8Error struct {
+	Line   int
+	Offset int
+}
+
+func (e *UTF8Error) Error() string {
+	return fmt.Sprintf("%v at line %d, offset %d", ErrInvalidUTF8, e.Line, e.Offset)
+}
+
+func (e *UTF8Error) Unwrap() error {
+	return ErrInvalidUTF8
+}
+
+// WithUTF8Policy validates every line before it is passed to the filter.
+func WithUTF8Policy(p UTF8Policy) StreamOption {
+	return func(sr *StreamReader) { sr.utf8Policy = p }
+}
+
+// WithStripBOM removes a UTF-8 byte order mark from the start of the first
+// line. BOMDetected reports whether one was seen either way.
+func WithStripBOM() StreamOption {
+	return func(sr *StreamReader) { sr.stripBOM = true }
+}
+
+// BOMDetected reports whether the first line started with a UTF-8 BOM.
+func (sr *StreamReader) BOMDetected() bool {
+	return sr != nil && sr.bomDetected
+}
+
+// checkUTF8 applies the UTF-8 policy to a line. It returns false when the
+// line must be dropped.
+func (sr *StreamReader) checkUTF8(line string) (string, bool, error) {
+	if sr.utf8Policy == UTF8Pass || utf8.ValidString(line) {
+		return line, true, nil
+	}
+	switch sr.utf8Policy {
+	case UTF8Replace:
+		return strings.ToValidUTF8(line, "\uFFFD"), true, nil
+	case UTF8Drop:
+		return "", false, nil
+	}
+	return "", false, &UTF8Error{Line: sr.lines, Offset: invalidUTF8Offset(line)}
+}
+
+// invalidUTF8Offset returns the offset of the first invalid byte in line,
+// which must not be valid UTF-8.
+func invalidUTF8Offset(line string) int {
+	for i := 0; ; {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+}
@@ -0,0 +1,94 @@
+package go_sio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStreamReader_UTF8Policy(t *testing.T) {
+	data := "ok\nbad \xff\xfe byte\ncut \xe4\xb8\n世界\n"
+	tests := []struct {
+		name     string
+		policy   UTF8Policy
+		expected string
+	}{
+		{"pass", UTF8Pass, data},
+		{"replace", UTF8Replace, "ok\nbad \uFFFD byte\ncut \uFFFD\n世界\n"},
+		{"drop", UTF8Drop, "ok\n世界\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := io.ReadAll(NewStreamReader(strings.NewReader(data), NopFilter, WithUTF8Policy(tt.policy)))
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(out))
+			}
+		})
+	}
+}
+
+func TestStreamReader_UTF8Fail(t *testing.T) {
+	filterCalls := 0
+	filter := func(in string) (string, error) {
+		filterCalls++
+		return in, nil
+	}
+	sr := NewStreamReader(strings.NewReader("first\nsecond 世\x80界\n"), filter, WithUTF8Policy(UTF8Fail))
+
+	out, err := io.ReadAll(sr)
+	var utf8Err *UTF8Error
+	if !errors.As(err, &utf8Err) || !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("Expected *UTF8Error, got %v", err)
+	}
+	if utf8Err.Line != 2 || utf8Err.Offset != 10 {
+		t.Errorf("Expected line 2 offset 10, got line %d offset %d", utf8Err.Line, utf8Err.Offset)
+	}
+	if err.Error() != "invalid UTF-8 at line 2, offset 10" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+	if string(out) != "first\n" || filterCalls != 1 {
+		t.Errorf("Expected only the valid line to be filtered, got %q after %d calls", string(out), filterCalls)
+	}
+}
+
+func TestStreamReader_StripBOM(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		opts     []StreamOption
+		expected string
+		detected bool
+	}{
+		{"stripped", "\uFEFF{\"a\":1}\n\uFEFFkept\n", []StreamOption{WithStripBOM()}, "{\"a\":1}\n\uFEFFkept\n", true},
+		{"detected only", "\uFEFFx\n", nil, "\uFEFFx\n", true},
+		{"no bom", "x\n", []StreamOption{WithStripBOM()}, "x\n", false},
+		{"bom only", "\uFEFF", []StreamOption{WithStripBOM()}, "", true},
+		{"with utf8 policy", "\uFEFFa\xff\n", []StreamOption{WithStripBOM(), WithUTF8Policy(UTF8Replace)}, "a\uFFFD\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := NewStreamReader(strings.NewReader(tt.data), NopFilter, tt.opts...)
+			out, err := io.ReadAll(sr)
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(out))
+			}
+			if sr.BOMDetected() != tt.detected {
+				t.Errorf("Expected BOMDetected %v, got %v", tt.detected, sr.BOMDetected())
+			}
+		})
+	}
+
+	var sr *StreamReader
+	if sr.BOMDetected() {
+		t.Error("Expected false for nil StreamReader")
+	}
+}